@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"rest-app/config"
+)
+
+// command a subcommand, run returns the process exit code
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) int
+}
+
+func commands() []command {
+	return []command{
+		{name: "serve", usage: "serve", summary: "start the http server, the default", run: serve},
+		{name: "migrate", usage: "migrate up | down [N] | status | force VERSION", summary: "apply or revert the embedded database migrations", run: migrate},
+		{name: "ocr", usage: "ocr [--tenant ID] FILE", summary: "extract a receipt image through the full pipeline and print its JSON", run: ocr},
+		{name: "eval", usage: "eval [--tenant ID] [--out FILE] [--baseline FILE] [--fail-on-regression] [--concurrency N] DIR", summary: "measure extraction accuracy and latency on a labelled corpus", run: evalCmd},
+		{name: "examples", usage: "examples list | add --text FILE --json FILE [--bank NAME] [--tenant ID] | promote RECEIPT_ID --json FILE [--shared] | remove ID", summary: "curate the few-shot examples added to extraction prompts", run: examples},
+		{name: "user", usage: "user create --username NAME --tenant ID [--role admin|user] [--password-stdin] [--token-ttl 24h]", summary: "create an account", run: user},
+		{name: "config", usage: "config print [--redacted=false]", summary: "print the effective configuration and its problems", run: configCmd},
+	}
+}
+
+// Run executes the subcommand named by args[0], without arguments the server is started
+func Run(args []string) int {
+	if len(args) == 0 {
+		return serve(nil)
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage()
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	usage()
+	return 2
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rest-app <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	for _, cmd := range commands() {
+		fmt.Fprintf(os.Stderr, "  %s\n        %s\n", cmd.usage, cmd.summary)
+	}
+}
+
+// usageError prints how to call name and returns the exit code of a bad invocation
+func usageError(name string) int {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			fmt.Fprintln(os.Stderr, "usage: rest-app", cmd.usage)
+		}
+	}
+	return 2
+}
+
+// fail prints err for the command name and returns the exit code of a failure
+func fail(name string, err error) int {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+	return 1
+}
+
+// loadConfig loads the configuration, with prefixes only problems of keys starting with one of
+// them fail, so commands don't need settings they never use. A config file that can't be read always fails
+func loadConfig(prefixes ...string) error {
+	_, err := config.Load()
+
+	var validationErr *config.ValidationError
+	if len(prefixes) == 0 || !errors.As(err, &validationErr) {
+		return err
+	}
+
+	var problems []config.Problem
+	for _, p := range validationErr.Problems {
+		if p.Key == "config file" || hasAnyPrefix(p.Key, prefixes) {
+			problems = append(problems, p)
+		}
+	}
+	if len(problems) > 0 {
+		return &config.ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"rest-app/config"
+)
+
+// configCmd prints the effective configuration, then its problems if any
+func configCmd(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		return usageError("config")
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := flags.Bool("redacted", true, "hide tokens, secrets and DSNs, --redacted=false shows them")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	_, loadErr := config.Load()
+	if err := config.Print(os.Stdout, *redacted); err != nil {
+		return fail("config", err)
+	}
+	if loadErr != nil {
+		fmt.Fprintln(os.Stderr, loadErr)
+		return 1
+	}
+	return 0
+}
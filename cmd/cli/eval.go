@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"rest-app/config"
+	"rest-app/internal/app/ocr/eval"
+	appSetup "rest-app/internal/setup"
+	"rest-app/pkg/lifecycle"
+	"rest-app/pkg/tenant"
+)
+
+// evalCmd measures extraction accuracy on a labelled corpus, see eval.LoadCorpus for its layout.
+// Results are never stored. The summary goes to stdout, logs to stderr
+func evalCmd(args []string) int {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	tenantID := flags.String("tenant", "", "use the settings (provider, document types) of this tenant")
+	out := flags.String("out", "", "write the full report as JSON to this file, the baseline of the next run")
+	baselinePath := flags.String("baseline", "", "compare with the JSON report of a previous run")
+	concurrency := flags.Int("concurrency", 1, "samples extracted at once")
+	failOnRegression := flags.Bool("fail-on-regression", false, "exit with 1 when a field matching in the baseline doesn't anymore")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *concurrency < 1 {
+		return usageError("eval")
+	}
+
+	samples, err := eval.LoadCorpus(flags.Arg(0))
+	if err != nil {
+		return fail("eval", err)
+	}
+
+	var baseline *eval.Report
+	if *baselinePath != "" {
+		content, err := os.ReadFile(*baselinePath)
+		if err != nil {
+			return fail("eval", err)
+		}
+		baseline = &eval.Report{}
+		if err := json.Unmarshal(content, baseline); err != nil {
+			return fail("eval", fmt.Errorf("%s: %w", *baselinePath, err))
+		}
+	}
+
+	if err := loadConfig(); err != nil {
+		return fail("eval", err)
+	}
+
+	ctx := context.Background()
+	lc := lifecycle.New()
+	setup := appSetup.Init(lc, os.Stderr)
+	if err := lc.Start(ctx); err != nil {
+		return fail("eval", err)
+	}
+	defer func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), config.GetConfig().Shutdown.Timeout)
+		defer cancel()
+		_ = lc.Stop(stopCtx)
+	}()
+
+	if *tenantID != "" {
+		ctx = tenant.WithTenantID(ctx, *tenantID)
+	}
+
+	report := eval.Run(ctx, setup.InternalApp.Services.UnsavedOCRService.Extract, flags.Arg(0), samples, *concurrency)
+
+	if *out != "" {
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fail("eval", err)
+		}
+		if err := os.WriteFile(*out, append(content, '\n'), 0o644); err != nil {
+			return fail("eval", err)
+		}
+	}
+
+	if err := eval.Print(os.Stdout, report, baseline); err != nil {
+		return fail("eval", err)
+	}
+	if baseline != nil && *failOnRegression && len(eval.Compare(baseline, report)) > 0 {
+		return 1
+	}
+	return 0
+}
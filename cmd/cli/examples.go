@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"rest-app/config"
+	"rest-app/config/db"
+	exampleModel "rest-app/internal/app/example/model"
+	examplePort "rest-app/internal/app/example/port"
+	exampleRepo "rest-app/internal/app/example/repository"
+	exampleService "rest-app/internal/app/example/service"
+	ocrRepo "rest-app/internal/app/ocr/repository"
+)
+
+// examples curates the few-shot example library
+func examples(args []string) int {
+	if len(args) == 0 {
+		return usageError("examples")
+	}
+
+	var run func(ctx context.Context, svc examplePort.IExampleService, args []string) error
+	switch args[0] {
+	case "list":
+		run = listExamples
+	case "add":
+		run = addExample
+	case "promote":
+		run = promoteExample
+	case "remove":
+		run = removeExample
+	default:
+		return usageError("examples")
+	}
+
+	if err := loadConfig("DB_"); err != nil {
+		return fail("examples", err)
+	}
+	conf := config.GetConfig()
+	if conf.DB.DSN == "" {
+		return fail("examples", errors.New("DB_DSN is required"))
+	}
+
+	dbConfig, err := db.Init(conf.DB.DSN, conf.DB.DSNPool)
+	if err != nil {
+		return fail("examples", err)
+	}
+	defer dbConfig.CloseConnection()
+
+	svc := exampleService.NewExampleService(&conf.FewShot, exampleRepo.NewExampleDB(dbConfig.GormDB), ocrRepo.NewReceiptDB(dbConfig.GormDB))
+	if err := run(context.Background(), svc, args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			return usageError("examples")
+		}
+		return fail("examples", err)
+	}
+	return 0
+}
+
+var errUsage = errors.New("usage")
+
+func listExamples(ctx context.Context, svc examplePort.IExampleService, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	list, err := svc.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tBANK\tTENANT\tRECEIPT\tCREATED")
+	for _, example := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", example.ID, example.BankName, valueOr(example.TenantID, "shared"), valueOr(example.ReceiptID, "-"), example.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
+
+func addExample(ctx context.Context, svc examplePort.IExampleService, args []string) error {
+	flags := flag.NewFlagSet("examples add", flag.ContinueOnError)
+	textFile := flags.String("text", "", "file holding the OCR text, required")
+	jsonFile := flags.String("json", "", "file holding the receipt JSON expected from the text, required")
+	bank := flags.String("bank", "", "bank the layout belongs to, defaults to the bank_name of the JSON")
+	tenantID := flags.String("tenant", "", "only use the example for this tenant, shared by every tenant otherwise")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 || *textFile == "" || *jsonFile == "" {
+		return errUsage
+	}
+
+	text, err := os.ReadFile(*textFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(*jsonFile)
+	if err != nil {
+		return err
+	}
+
+	example := &exampleModel.Example{BankName: *bank, OCRText: string(text), Data: data}
+	if *tenantID != "" {
+		example.TenantID = tenantID
+	}
+	if err := svc.Add(ctx, example); err != nil {
+		return err
+	}
+
+	fmt.Printf("added example %s (bank %q)\n", example.ID, example.BankName)
+	return nil
+}
+
+func promoteExample(ctx context.Context, svc examplePort.IExampleService, args []string) error {
+	flags := flag.NewFlagSet("examples promote", flag.ContinueOnError)
+	jsonFile := flags.String("json", "", "file holding the corrected receipt JSON, required")
+	shared := flags.Bool("shared", false, "use the example for every tenant, not only the receipt's")
+	if len(args) == 0 {
+		return errUsage
+	}
+	receiptID := args[0]
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 || *jsonFile == "" {
+		return errUsage
+	}
+
+	corrected, err := os.ReadFile(*jsonFile)
+	if err != nil {
+		return err
+	}
+
+	example, err := svc.Promote(ctx, receiptID, corrected, *shared)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("promoted receipt %s to example %s (bank %q, %s)\n", receiptID, example.ID, example.BankName, valueOr(example.TenantID, "shared"))
+	return nil
+}
+
+func removeExample(ctx context.Context, svc examplePort.IExampleService, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	if err := svc.Delete(ctx, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Println("removed example", args[0])
+	return nil
+}
+
+func valueOr(value *string, fallback string) string {
+	if value == nil || *value == "" {
+		return fallback
+	}
+	return *value
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	_ "github.com/lib/pq"
+
+	"rest-app/config"
+	"rest-app/migrations"
+	pkgMigrate "rest-app/pkg/migrate"
+)
+
+// migrate applies, reverts or reports the migrations embedded from the migrations directory
+func migrate(args []string) int {
+	if len(args) == 0 {
+		return usageError("migrate")
+	}
+
+	if err := loadConfig("DB_"); err != nil {
+		return fail("migrate", err)
+	}
+	dsn := config.GetConfig().DB.DSN
+	if dsn == "" {
+		return fail("migrate", errors.New("DB_DSN is required"))
+	}
+
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return fail("migrate", err)
+	}
+	defer sqlDB.Close()
+
+	migrator, err := pkgMigrate.New(sqlDB, migrations.FS)
+	if err != nil {
+		return fail("migrate", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return usageError("migrate")
+		}
+		applied, err := migrator.Up(ctx)
+		printMigrations("applied", applied)
+		if err != nil {
+			return fail("migrate", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no change")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return usageError("migrate")
+			}
+		} else if len(args) > 2 {
+			return usageError("migrate")
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		printMigrations("reverted", reverted)
+		if err != nil {
+			return fail("migrate", err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no change")
+		}
+
+	case "status":
+		if len(args) != 1 {
+			return usageError("migrate")
+		}
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return fail("migrate", err)
+		}
+		fmt.Printf("version %d", status.Version)
+		if status.Dirty {
+			fmt.Print(" (dirty)")
+		}
+		fmt.Println()
+		printMigrations("applied", status.Applied)
+		printMigrations("pending", status.Pending)
+
+	case "force":
+		if len(args) != 2 {
+			return usageError("migrate")
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return usageError("migrate")
+		}
+		if err := migrator.Force(ctx, uint(version)); err != nil {
+			return fail("migrate", err)
+		}
+		fmt.Printf("version forced to %d\n", version)
+
+	default:
+		return usageError("migrate")
+	}
+
+	return 0
+}
+
+func printMigrations(state string, migrations []pkgMigrate.Migration) {
+	for _, m := range migrations {
+		fmt.Printf("%-8s %06d_%s\n", state, m.Version, m.Name)
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"rest-app/config"
+	appSetup "rest-app/internal/setup"
+	"rest-app/pkg/lifecycle"
+	"rest-app/pkg/tenant"
+)
+
+// ocr runs one image through the same pipeline as the http endpoint, the result is printed
+// as JSON on stdout and logs go to stderr. With a database and a tenant it is stored like any
+// other result, receipts always belong to a tenant
+func ocr(args []string) int {
+	flags := flag.NewFlagSet("ocr", flag.ContinueOnError)
+	tenantID := flags.String("tenant", "", "use the settings (provider, document types) of this tenant")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return usageError("ocr")
+	}
+
+	imgBytes, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fail("ocr", err)
+	}
+
+	if err := loadConfig(); err != nil {
+		return fail("ocr", err)
+	}
+
+	ctx := context.Background()
+	lc := lifecycle.New()
+	setup := appSetup.Init(lc, os.Stderr)
+	if err := lc.Start(ctx); err != nil {
+		return fail("ocr", err)
+	}
+	defer func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), config.GetConfig().Shutdown.Timeout)
+		defer cancel()
+		_ = lc.Stop(stopCtx)
+	}()
+
+	service := setup.InternalApp.Services.UnsavedOCRService
+	if *tenantID != "" {
+		ctx = tenant.WithTenantID(ctx, *tenantID)
+		service = setup.InternalApp.Services.OCRService
+	}
+
+	res, err := service.ReceiptDataGenerator(ctx, imgBytes)
+	if err != nil {
+		return fail("ocr", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(res); err != nil {
+		return fail("ocr", err)
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"rest-app/cmd/rest"
+	"rest-app/config"
+	appSetup "rest-app/internal/setup"
+	"rest-app/pkg/lifecycle"
+	"rest-app/pkg/tracing"
+)
+
+// serve runs the http server until SIGINT or SIGTERM, then shuts down within SHUTDOWN_TIMEOUT
+func serve(args []string) int {
+	if len(args) > 0 {
+		return usageError("serve")
+	}
+
+	if err := loadConfig(); err != nil {
+		return fail("serve", err)
+	}
+
+	ctx := context.Background()
+	conf := config.GetConfig()
+
+	// components stop in reverse order: http, tesseract, db, then tracing flushes what they recorded
+	lc := lifecycle.New()
+
+	// Tracing
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		ServiceName:    "rest-app",
+		ServiceVersion: conf.App.Version,
+		Environment:    conf.App.Env,
+		Exporter:       conf.Tracing.Exporter,
+		OTLPEndpoint:   conf.Tracing.OTLPEndpoint,
+		OTLPInsecure:   conf.Tracing.OTLPInsecure,
+		FilePath:       conf.Tracing.FilePath,
+		SampleRatio:    conf.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Println("failed to init tracing:", err)
+		return 1
+	}
+	lc.Append(lifecycle.Hook{Name: "tracing", OnStop: shutdownTracing})
+
+	// App setup
+	setup := appSetup.Init(lc, os.Stdout)
+
+	// pick up config file changes, settings that need a restart are rejected
+	config.Watch()
+
+	// REST service
+	lc.Append(rest.ServerHook(rest.NewServer(setup)))
+
+	if err := lc.Start(ctx); err != nil {
+		log.Println("failed to start:", err)
+		return 1
+	}
+
+	// gracefull shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	// Wait for termination signal
+	<-quit
+
+	log.Println("Shutting down services...")
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), conf.Shutdown.Timeout)
+	defer cancel()
+	if err := lc.Stop(stopCtx); err != nil {
+		log.Println("Shutdown incomplete:", err)
+		return 1
+	}
+
+	log.Println("Server exited gracefully")
+	return 0
+}
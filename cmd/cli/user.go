@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"rest-app/cmd/rest/middleware"
+	"rest-app/config"
+	"rest-app/config/db"
+	userModel "rest-app/internal/app/user/model"
+	userRepo "rest-app/internal/app/user/repository"
+	userService "rest-app/internal/app/user/service"
+)
+
+// user creates accounts, e.g. the first admin of a deployment
+func user(args []string) int {
+	if len(args) == 0 || args[0] != "create" {
+		return usageError("user")
+	}
+
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	username := flags.String("username", "", "login name, required")
+	role := flags.String("role", userModel.RoleAdmin, "admin or user")
+	tenantID := flags.String("tenant", "", "tenant the user belongs to, required")
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin, otherwise one is generated and printed")
+	tokenTTL := flags.Duration("token-ttl", 0, "also print a JWT valid this long, signed with SIGNING_KEY")
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 || *username == "" || *tenantID == "" {
+		return usageError("user")
+	}
+
+	password, generated := "", false
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fail("user", fmt.Errorf("failed to read password: %w", err))
+		}
+		password = strings.TrimRight(line, "\r\n")
+	} else {
+		var err error
+		if password, err = generatePassword(); err != nil {
+			return fail("user", err)
+		}
+		generated = true
+	}
+
+	if err := loadConfig("DB_", "SIGNING_KEY"); err != nil {
+		return fail("user", err)
+	}
+	conf := config.GetConfig()
+	if conf.DB.DSN == "" {
+		return fail("user", errors.New("DB_DSN is required"))
+	}
+
+	dbConfig, err := db.Init(conf.DB.DSN, conf.DB.DSNPool)
+	if err != nil {
+		return fail("user", err)
+	}
+	defer dbConfig.CloseConnection()
+
+	ctx := context.Background()
+	created, err := userService.NewUserService(userRepo.NewUserDB(dbConfig.GormDB)).Create(ctx, userModel.CreateUser{
+		Username: *username,
+		Password: password,
+		Role:     *role,
+		TenantID: *tenantID,
+	})
+	if err != nil {
+		return fail("user", err)
+	}
+
+	fmt.Printf("created %s user %s (id %s)\n", created.Role, created.Username, created.ID)
+	if generated {
+		fmt.Println("password:", password)
+	}
+
+	if *tokenTTL > 0 {
+		token, err := middleware.GenerateJWTToken(middleware.JWTClaims{
+			ID:       created.ID,
+			Username: created.Username,
+			TenantID: *tenantID,
+		}, *tokenTTL)
+		if err != nil {
+			return fail("user", err)
+		}
+		fmt.Println("token:", token)
+	}
+	return 0
+}
+
+func generatePassword() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"rest-app/config"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsPolicy is a CORSConf compiled once for every request
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	patterns         []*regexp.Regexp
+	allowMethods     string
+	anyHeader        bool
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+func newCORSPolicy(conf config.CORSConf) *corsPolicy {
+	p := &corsPolicy{
+		origins:          map[string]bool{},
+		allowMethods:     strings.Join(conf.AllowedMethods, ", "),
+		allowHeaders:     strings.Join(conf.AllowedHeaders, ", "),
+		exposeHeaders:    strings.Join(conf.ExposedHeaders, ", "),
+		allowCredentials: conf.AllowCredentials,
+		maxAge:           strconv.Itoa(int(conf.MaxAge.Seconds())),
+	}
+
+	for _, origin := range conf.AllowedOrigins {
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			// a wildcard stands for one or more host name or port characters, never for a path
+			pattern := strings.ReplaceAll(regexp.QuoteMeta(strings.ToLower(origin)), `\*`, `[a-z0-9.-]+`)
+			p.patterns = append(p.patterns, regexp.MustCompile("^"+pattern+"$"))
+		default:
+			p.origins[strings.ToLower(origin)] = true
+		}
+	}
+
+	for _, header := range conf.AllowedHeaders {
+		if header == "*" {
+			p.anyHeader = true
+		}
+	}
+
+	// browsers refuse credentials with a wildcard origin, and echoing any origin with credentials would hand them to every site
+	if p.anyOrigin {
+		p.allowCredentials = false
+	}
+
+	return p
+}
+
+func (p *corsPolicy) allows(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSMiddleware applies conf to cross origin requests, preflights are answered with 204 here and never reach the routes.
+// The policy follows configuration reloads
+func CORSMiddleware(conf config.CORSConf) gin.HandlerFunc {
+	var current atomic.Pointer[corsPolicy]
+	current.Store(newCORSPolicy(conf))
+	config.Subscribe(func(conf config.Config) {
+		current.Store(newCORSPolicy(conf.CORS))
+	})
+
+	return func(c *gin.Context) {
+		policy := current.Load()
+		origin := c.Request.Header.Get("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.Request.Header.Get("Access-Control-Request-Method") != ""
+
+		header := c.Writer.Header()
+		if !policy.anyOrigin {
+			header.Add("Vary", "Origin")
+		}
+
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		if !policy.allows(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// the browser blocks the response without CORS headers
+			c.Next()
+			return
+		}
+
+		if policy.anyOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if policy.exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", policy.allowMethods)
+		if policy.anyHeader {
+			header.Set("Access-Control-Allow-Headers", c.Request.Header.Get("Access-Control-Request-Headers"))
+		} else if policy.allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+		}
+		header.Set("Access-Control-Max-Age", policy.maxAge)
+
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"rest-app/config"
 	"strings"
+	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
 )
@@ -14,13 +15,17 @@ type JWTClaims struct {
 	Username  string `json:"username"`
 	FirstName string `json:"firstname"`
 	LastName  string `json:"lastname"`
+	TenantID  string `json:"tenant_id"`
 }
 
 func ParseJWTToken(tokenString string) (*JWTClaims, error) {
 	configData := config.GetConfig()
 	secretKey := configData.JWT.SigningKey
 
-	tokenString = strings.Split(tokenString, "Bearer ")[1]
+	tokenString, ok := strings.CutPrefix(tokenString, "Bearer ")
+	if !ok {
+		return nil, errors.New("invalid authorization header")
+	}
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (i interface{}, err error) {
 		return []byte(secretKey), nil
 	})
@@ -34,3 +39,13 @@ func ParseJWTToken(tokenString string) (*JWTClaims, error) {
 
 	return nil, errors.New("invalid jwt token")
 }
+
+// GenerateJWTToken signs claims with SIGNING_KEY, the token expires after ttl
+func GenerateJWTToken(claims JWTClaims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.GetConfig().JWT.SigningKey))
+}
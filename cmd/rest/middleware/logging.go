@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	"rest-app/pkg/logging"
+)
+
+// HeaderRequestID header carrying the request id, propagated when sent by the client
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength longer client ids are replaced, they end up in every log line
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware assigns or propagates X-Request-ID and binds a logger
+// carrying it (and the trace id) to the request context
+func RequestIDMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.Request.Header.Get(HeaderRequestID)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		c.Set("request_id", requestID)
+		c.Header(HeaderRequestID, requestID)
+
+		requestLogger := logger.With(slog.String("request_id", requestID))
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.HasTraceID() {
+			requestLogger = requestLogger.With(slog.String("trace_id", spanContext.TraceID().String()))
+		}
+
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), requestLogger))
+	}
+}
+
+// AccessLogMiddleware writes one structured line per request
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+			slog.Int("response_size", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if userID := c.GetString("id"); userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+		if tenantID := c.GetString("tenant_id"); tenantID != "" {
+			attrs = append(attrs, slog.String("tenant_id", tenantID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
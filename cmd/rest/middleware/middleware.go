@@ -1,49 +1,92 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"rest-app/pkg/apperror"
+	"rest-app/pkg/captcha"
 	"rest-app/pkg/helper"
+	"rest-app/pkg/tenant"
 
 	"github.com/gin-gonic/gin"
+
+	tenantModel "rest-app/internal/app/tenant/model"
+	tenantPort "rest-app/internal/app/tenant/port"
 )
 
-func CORSMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-App-Id, X-Client-Id, X-Client-Version, X-Mock-Data")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		if c.Request.Method == http.MethodOptions {
-			c.AbortWithStatus(200)
-		} else {
-			c.Next()
-		}
-	}
-}
+const (
+	// HeaderAPIKey header carrying a tenant api key
+	HeaderAPIKey = "X-API-Key"
+	// HeaderCaptchaToken header carrying the captcha token solved by an anonymous client
+	HeaderCaptchaToken = "X-Captcha-Token"
+)
 
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// already authenticated by APIKeyAuthMiddleware
+		if _, ok := c.Get("api_key_id"); ok {
+			return
+		}
+
 		authHeader := c.Request.Header.Get("Authorization")
 		if len(authHeader) == 0 {
-			c.AbortWithStatusJSON(http.StatusForbidden, helper.Response{
-				Message: http.StatusText(http.StatusUnauthorized),
-				Success: false,
-			})
+			helper.ResponseError(c, apperror.New(apperror.Unauthorized, http.StatusText(http.StatusUnauthorized)))
 			return
 		}
 
 		claims, err := ParseJWTToken(authHeader)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusForbidden, helper.Response{
-				Message: err.Error(),
-				Success: false,
-			})
+			helper.ResponseError(c, apperror.Wrap(apperror.Unauthorized, err, "invalid token"))
+			return
+		}
+		if claims.TenantID == "" {
+			helper.ResponseError(c, tenantModel.ErrNoTenant)
 			return
 		}
 		c.Set("id", claims.ID)
 		c.Set("username", claims.Username)
+		setTenant(c, claims.TenantID)
+	}
+}
+
+// APIKeyAuthMiddleware authenticates requests carrying an X-API-Key header,
+// requests without the header are left to JWTAuthMiddleware
+func APIKeyAuthMiddleware(tenantService tenantPort.ITenantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.Request.Header.Get(HeaderAPIKey)
+		if len(apiKey) == 0 {
+			return
+		}
+
+		key, err := tenantService.AuthenticateAPIKey(c.Request.Context(), apiKey)
+		if err != nil {
+			// an unknown key is the client's fault, a failed lookup is ours and gets logged
+			if !errors.Is(err, tenantModel.ErrInvalidAPIKey) {
+				err = apperror.Wrap(apperror.Unavailable, err, "the api key could not be verified, retry the request")
+			}
+			helper.ResponseError(c, err)
+			return
+		}
+		c.Set("api_key_id", key.ID)
+		c.Set("id", key.UserID)
+		setTenant(c, key.TenantID)
 	}
 }
+
+// CaptchaMiddleware rejects requests whose captcha token can't be verified
+func CaptchaMiddleware(verifier captcha.IVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := verifier.Verify(c.Request.Context(), c.Request.Header.Get(HeaderCaptchaToken), c.ClientIP())
+		if err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+	}
+}
+
+// setTenant binds the tenant to both the gin and the request context, callers reject
+// credentials without one
+func setTenant(c *gin.Context, tenantID string) {
+	c.Set("tenant_id", tenantID)
+	c.Request = c.Request.WithContext(tenant.WithTenantID(c.Request.Context(), tenantID))
+}
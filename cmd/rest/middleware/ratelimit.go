@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"rest-app/pkg/helper"
+	"rest-app/pkg/logging"
+	"rest-app/pkg/ratelimit"
+
+	tenantPort "rest-app/internal/app/tenant/port"
+)
+
+// RateLimitKeyFunc returns the bucket key of a request
+type RateLimitKeyFunc func(c *gin.Context) string
+
+func RateLimitKeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitKeyByUser falls back to the client ip for anonymous requests
+func RateLimitKeyByUser(c *gin.Context) string {
+	if id := c.GetString("id"); id != "" {
+		return "user:" + id
+	}
+	return RateLimitKeyByIP(c)
+}
+
+// RateLimitKeyByAPIKey only trusts keys authenticated by APIKeyAuthMiddleware,
+// otherwise random keys could be used to get fresh buckets
+func RateLimitKeyByAPIKey(c *gin.Context) string {
+	if id := c.GetString("api_key_id"); id != "" {
+		return "api_key:" + id
+	}
+	return RateLimitKeyByIP(c)
+}
+
+// RateLimitKeyAnonymous keeps anonymous buckets apart from authenticated ones
+func RateLimitKeyAnonymous(c *gin.Context) string {
+	return "anonymous:" + RateLimitKeyByIP(c)
+}
+
+// GetRateLimitKeyFunc maps RATE_LIMIT_KEY_BY to a key func, auto prefers api key, then user, then ip
+func GetRateLimitKeyFunc(keyBy string) RateLimitKeyFunc {
+	switch keyBy {
+	case "ip":
+		return RateLimitKeyByIP
+	case "user":
+		return RateLimitKeyByUser
+	case "api_key":
+		return RateLimitKeyByAPIKey
+	default:
+		return func(c *gin.Context) string {
+			if c.GetString("api_key_id") != "" {
+				return RateLimitKeyByAPIKey(c)
+			}
+			return RateLimitKeyByUser(c)
+		}
+	}
+}
+
+// RateLimitFunc returns the limit applied to the next request, read per request so reloads apply
+type RateLimitFunc func() ratelimit.Limit
+
+func RateLimitMiddleware(limiter ratelimit.ILimiter, limitFunc RateLimitFunc, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res, err := limiter.Take(c.Request.Context(), keyFunc(c), limitFunc())
+		if err != nil {
+			// fail open, an unavailable backend must not take the API down
+			logging.FromContext(c.Request.Context()).Error("rate limiter failed", slog.Any("error", err))
+			return
+		}
+
+		setRateLimitHeaders(c, res.Limit, res.Remaining, res.ResetAfter)
+		if !res.Allowed {
+			abortTooManyRequests(c, res.RetryAfter, ratelimit.ErrRateLimited)
+		}
+	}
+}
+
+// QuotaLimitFunc returns the monthly quota of a request, negative means unlimited
+type QuotaLimitFunc func(c *gin.Context) (int, error)
+
+// TenantPlanQuota limits requests by the plan of their tenant, unknown plans are unlimited
+func TenantPlanQuota(tenantService tenantPort.ITenantService, plans func() map[string]int) QuotaLimitFunc {
+	return func(c *gin.Context) (int, error) {
+		settings, err := tenantService.GetSettings(c.Request.Context())
+		if err != nil {
+			return 0, err
+		}
+
+		limit, ok := plans()[settings.Plan]
+		if !ok {
+			return -1, nil
+		}
+		return limit, nil
+	}
+}
+
+// FixedQuota limits every request to the same quota
+func FixedQuota(limit func() int) QuotaLimitFunc {
+	return func(c *gin.Context) (int, error) {
+		return limit(), nil
+	}
+}
+
+// OCRQuotaMiddleware counts requests against a monthly quota, per tenant when the
+// request has one, otherwise per keyFunc. Requests failing with a server error are refunded
+func OCRQuotaMiddleware(quota ratelimit.IQuota, limitFunc QuotaLimitFunc, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := limitFunc(c)
+		if err != nil {
+			helper.ResponseError(c, err)
+			return
+		}
+		if limit < 0 {
+			return
+		}
+
+		key := keyFunc(c)
+		if tenantID := c.GetString("tenant_id"); tenantID != "" {
+			key = "tenant:" + tenantID
+		}
+
+		res, err := quota.Consume(c.Request.Context(), key, limit)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("ocr quota failed", slog.Any("error", err))
+			return
+		}
+
+		resetAfter := time.Until(res.ResetAt)
+		setRateLimitHeaders(c, res.Limit, res.Remaining, resetAfter)
+		if !res.Allowed {
+			abortTooManyRequests(c, resetAfter, ratelimit.ErrQuotaExceeded)
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			if err := quota.Refund(c.Request.Context(), key); err != nil {
+				logging.FromContext(c.Request.Context()).Error("ocr quota refund failed", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+func setRateLimitHeaders(c *gin.Context, limit, remaining int, resetAfter time.Duration) {
+	c.Header("RateLimit-Limit", strconv.Itoa(limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(durationToSeconds(resetAfter)))
+}
+
+func abortTooManyRequests(c *gin.Context, retryAfter time.Duration, err error) {
+	c.Header("Retry-After", strconv.Itoa(durationToSeconds(retryAfter)))
+	helper.ResponseError(c, err)
+}
+
+func durationToSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,230 @@
+package rest
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rest-app/cmd/rest/middleware"
+	"rest-app/config"
+	"rest-app/pkg/apperror"
+	"rest-app/pkg/helper"
+	"rest-app/pkg/openapi"
+
+	"rest-app/internal/setup"
+
+	healthModel "rest-app/internal/app/health/model"
+	ocrModel "rest-app/internal/app/ocr/model"
+)
+
+const (
+	openAPIPath    = "/openapi.json"
+	docsPath       = "/docs"
+	docsAssetsPath = docsPath + "/assets"
+
+	securityBearer = "bearerAuth"
+	securityAPIKey = "apiKeyAuth"
+)
+
+// initOpenAPIRoute serves the spec and its UI, the UI loads the embedded swagger-ui assets
+func initOpenAPIRoute(router *gin.Engine, doc *openapi.Document) {
+	ui, err := openapi.UI(doc.Info.Title, openAPIPath, docsAssetsPath)
+	if err != nil {
+		log.Fatalln("failed to render openapi ui:", err)
+	}
+
+	router.GET(openAPIPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	})
+	router.GET(docsPath, func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", ui)
+	})
+	router.GET(docsAssetsPath+"/*file", gin.WrapH(http.StripPrefix(docsAssetsPath, http.FileServer(http.FS(openapi.UIAssets())))))
+}
+
+// buildOpenAPI documents every route registered by newRouter, keep it in sync with initRoute and initPublicRoute
+func buildOpenAPI(conf config.Config) *openapi.Document {
+	version := conf.App.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	doc := openapi.New(openapi.Info{
+		Title:       "rest-app",
+		Description: "Extracts structured transaction data from receipt images with OCR and an LLM.",
+		Version:     version,
+	})
+
+	doc.Components.SecuritySchemes[securityBearer] = &openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+	doc.Components.SecuritySchemes[securityAPIKey] = &openapi.SecurityScheme{Type: "apiKey", In: "header", Name: middleware.HeaderAPIKey}
+
+	doc.Register("Response", helper.Response{})
+	errorData := doc.Register("ResponseErrorData", helper.ResponseErrorData{})
+	receipt := doc.Register("ReceiptResult", ocrModel.ReceiptResult{})
+	doc.Components.Schemas["Violation"] = openapi.SchemaOf(ocrModel.Violation{})
+	doc.Components.Schemas["ReceiptResult"].Properties["warnings"].Items = openapi.Ref("Violation")
+	report := doc.Register("HealthReport", healthModel.Report{})
+	doc.Components.Schemas["ComponentStatus"] = openapi.SchemaOf(healthModel.ComponentStatus{})
+	doc.Components.Schemas["HealthReport"].Properties["components"].Items = openapi.Ref("ComponentStatus")
+	errorType := doc.Components.Schemas["ResponseErrorData"].Properties["type"]
+	for _, kind := range apperror.Kinds() {
+		errorType.Enum = append(errorType.Enum, kind.Type)
+	}
+
+	errorResponse := func(description string) *openapi.Response {
+		return jsonResponse(description, envelope(errorData))
+	}
+
+	doc.AddOperation(http.MethodGet, "/metrics", &openapi.Operation{
+		OperationID: "metrics",
+		Summary:     "Prometheus metrics",
+		Tags:        []string{"observability"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Metrics in the Prometheus text format", Content: map[string]*openapi.MediaType{
+				"text/plain": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/healthz", &openapi.Operation{
+		OperationID: "liveness",
+		Summary:     "Liveness probe, never checks dependencies",
+		Tags:        []string{"health"},
+		Responses: map[string]*openapi.Response{
+			"200": jsonResponse("The process serves http", envelope(report)),
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/readyz", &openapi.Operation{
+		OperationID: "readiness",
+		Summary:     "Readiness probe reporting every dependency",
+		Tags:        []string{"health"},
+		Responses: map[string]*openapi.Response{
+			"200": jsonResponse("Every critical dependency is up", envelope(report)),
+			"503": jsonResponse("A critical dependency is down", envelope(report)),
+		},
+	})
+	doc.AddOperation(http.MethodGet, openAPIPath, &openapi.Operation{
+		OperationID: "openapi",
+		Summary:     "This specification",
+		Tags:        []string{"docs"},
+		Responses: map[string]*openapi.Response{
+			"200": jsonResponse("OpenAPI 3 document", &openapi.Schema{Type: "object"}),
+		},
+	})
+	doc.AddOperation(http.MethodGet, docsPath, &openapi.Operation{
+		OperationID: "docs",
+		Summary:     "Swagger UI for this specification",
+		Tags:        []string{"docs"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "HTML page", Content: map[string]*openapi.MediaType{
+				"text/html": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+		},
+	})
+	doc.AddOperation(http.MethodGet, docsAssetsPath+"/{file}", &openapi.Operation{
+		OperationID: "docsAssets",
+		Summary:     "Script and stylesheet of the Swagger UI, embedded in the binary",
+		Tags:        []string{"docs"},
+		Parameters: []openapi.Parameter{{
+			Name:     "file",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string"},
+		}},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Asset of the pinned swagger-ui-dist release"},
+			"404": {Description: "Unknown asset"},
+		},
+	})
+
+	receiptOperation := func(operationID, summary string) *openapi.Operation {
+		return &openapi.Operation{
+			OperationID: operationID,
+			Summary:     summary,
+			Tags:        []string{"ocr"},
+			RequestBody: &openapi.RequestBody{
+				Required: true,
+				Content: map[string]*openapi.MediaType{
+					"multipart/form-data": {Schema: &openapi.Schema{
+						Type: "object",
+						Properties: map[string]*openapi.Schema{
+							"file": {Type: "string", Format: "binary", Description: "Receipt image, JPEG, PNG, WebP or TIFF detected from its content, OCR_MAX_UPLOAD_BYTES max"},
+						},
+						Required: []string{"file"},
+					}},
+				},
+			},
+			Responses: map[string]*openapi.Response{
+				"200": withRateLimitHeaders(jsonResponse("Extracted transaction", envelope(receipt))),
+				"400": errorResponse("ValidationFailed: not a multipart request or the file is missing"),
+				"401": errorResponse("Unauthorized: missing or invalid token or api key"),
+				"403": errorResponse("Forbidden: unknown tenant, document type not allowed for the tenant or captcha rejected"),
+				"413": errorResponse("PayloadTooLarge: the file or the image dimensions exceed the limits"),
+				"415": errorResponse("UnsupportedMediaType: the image format is not accepted or the image can't be decoded"),
+				"422": errorResponse("OCRLowQuality: no readable text was found in the image"),
+				"429": withRetryAfter(withRateLimitHeaders(errorResponse("TooManyRequests or QuotaExceeded: rate limit or monthly OCR quota exceeded"))),
+				"500": errorResponse("InternalServerError"),
+				"502": errorResponse("UpstreamProviderFailed: the LLM or captcha provider failed or returned an unusable result"),
+				"503": errorResponse("ServiceUnavailable: the server is shutting down or the api key could not be checked, retry the request"),
+			},
+		}
+	}
+
+	secured := receiptOperation("processReceipt", "Extract a receipt")
+	secured.Security = []openapi.SecurityRequirement{{securityBearer: {}}, {securityAPIKey: {}}}
+	doc.AddOperation(http.MethodPost, setup.BaseURL+"/ocr/receipt", secured)
+
+	if conf.Anonymous.Enabled {
+		public := receiptOperation("processReceiptAnonymous", "Extract a receipt without authentication, results are not stored")
+		public.Parameters = []openapi.Parameter{{
+			Name:        middleware.HeaderCaptchaToken,
+			In:          "header",
+			Description: "Solved captcha token, required when a captcha verifier is configured",
+			Schema:      &openapi.Schema{Type: "string"},
+		}}
+		doc.AddOperation(http.MethodPost, publicBaseURL+"/ocr/receipt", public)
+	}
+
+	return doc
+}
+
+// envelope is helper.Response with data narrowed to schema
+func envelope(data *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{
+		AllOf: []*openapi.Schema{
+			openapi.Ref("Response"),
+			{Type: "object", Properties: map[string]*openapi.Schema{"data": data}},
+		},
+	}
+}
+
+func jsonResponse(description string, schema *openapi.Schema) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content: map[string]*openapi.MediaType{
+			"application/json": {Schema: schema},
+		},
+	}
+}
+
+func withRateLimitHeaders(r *openapi.Response) *openapi.Response {
+	if r.Headers == nil {
+		r.Headers = map[string]*openapi.Header{}
+	}
+	for name, description := range map[string]string{
+		"RateLimit-Limit":     "Requests allowed in the current window",
+		"RateLimit-Remaining": "Requests left in the current window",
+		"RateLimit-Reset":     "Seconds until the window resets",
+	} {
+		r.Headers[name] = &openapi.Header{Description: description, Schema: &openapi.Schema{Type: "integer"}}
+	}
+	return r
+}
+
+func withRetryAfter(r *openapi.Response) *openapi.Response {
+	if r.Headers == nil {
+		r.Headers = map[string]*openapi.Header{}
+	}
+	r.Headers["Retry-After"] = &openapi.Header{Description: "Seconds to wait before retrying", Schema: &openapi.Schema{Type: "integer", Format: "int32"}}
+	return r
+}
@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"rest-app/config"
+	"rest-app/internal/setup"
+	"rest-app/pkg/openapi"
+)
+
+type stubHandler struct{}
+
+func (stubHandler) Liveness(*gin.Context)       {}
+func (stubHandler) Readiness(*gin.Context)      {}
+func (stubHandler) ProcessReceipt(*gin.Context) {}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var app setup.InternalAppStruct
+	app.Handler = setup.InitHandlerApp{
+		HealthCheckHandler:  stubHandler{},
+		OCRHandler:          stubHandler{},
+		AnonymousOCRHandler: stubHandler{},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		anonymous bool
+		rateLimit bool
+	}{
+		{name: "default"},
+		{name: "anonymous", anonymous: true},
+		{name: "rate limited", anonymous: true, rateLimit: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var conf config.Config
+			conf.Anonymous.Enabled = tc.anonymous
+			conf.RateLimit.Enabled = tc.rateLimit
+
+			var routes []openapi.Route
+			for _, r := range newRouter(conf, app).Routes() {
+				routes = append(routes, openapi.Route{Method: r.Method, Path: r.Path})
+			}
+
+			for _, d := range buildOpenAPI(conf).Drift(routes) {
+				t.Error(d)
+			}
+		})
+	}
+}
+
+func TestDocsServesEmbeddedAssets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	initOpenAPIRoute(router, buildOpenAPI(config.Config{}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	page := get(docsPath)
+	if page.Code != http.StatusOK || strings.Contains(page.Body.String(), "://") {
+		t.Errorf("GET %s = %d, want a page loading nothing from another host:\n%s", docsPath, page.Code, page.Body)
+	}
+	for _, asset := range []string{"swagger-ui.css", "swagger-ui-bundle.js"} {
+		if !strings.Contains(page.Body.String(), docsAssetsPath+"/"+asset) {
+			t.Errorf("GET %s doesn't load %s", docsPath, asset)
+		}
+		if w := get(docsAssetsPath + "/" + asset); w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Errorf("GET %s/%s = %d", docsAssetsPath, asset, w.Code)
+		}
+	}
+
+	version := get(docsAssetsPath + "/VERSION")
+	if version.Code != http.StatusOK || strings.TrimSpace(version.Body.String()) != openapi.UIVersion {
+		t.Errorf("GET %s/VERSION = %d %q, want %q", docsAssetsPath, version.Code, version.Body.String(), openapi.UIVersion)
+	}
+}
@@ -1,69 +1,142 @@
 package rest
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"rest-app/pkg/constants"
-	"rest-app/pkg/validations"
+	"rest-app/pkg/lifecycle"
+	"rest-app/pkg/ratelimit"
 
 	"rest-app/cmd/rest/middleware"
 	"rest-app/config"
 
 	"rest-app/internal/setup"
 
+	healthServer "rest-app/internal/app/health/server"
 	ocrServer "rest-app/internal/app/ocr/server"
 )
 
-func StartServer(setupData *setup.SetupData) *http.Server {
+// publicBaseURL base url of the anonymous api
+const publicBaseURL = "/v1/public-api"
+
+// NewServer builds the http server, ServerHook starts and stops it
+func NewServer(setupData *setup.SetupData) *http.Server {
 	conf := config.GetConfig()
 	if conf.App.Env == constants.PRODUCTION {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	return &http.Server{
+		Addr:    ":" + strconv.Itoa(conf.Http.Port),
+		Handler: newRouter(conf, setupData.InternalApp),
+	}
+}
+
+// newRouter registers the middlewares and every route, buildOpenAPI documents them
+func newRouter(conf config.Config, internalAppStruct setup.InternalAppStruct) *gin.Engine {
 	// GIN Init
-	router := gin.Default()
+	router := gin.New()
 	router.UseRawPath = true
-	validations.InitStructValidation()
-
-	// router.GET("/health", setupData.InternalApp.Handler.HealthCheckHandler.HealthCheck)
-
-	router.Use(middleware.CORSMiddleware())
-
-	initPublicRoute(router, setupData.InternalApp)
+	// let request context values (tenant, ...) be reachable through *gin.Context
+	router.ContextWithFallback = true
+
+	router.Use(otelgin.Middleware("rest-app"))
+	router.Use(middleware.RequestIDMiddleware(internalAppStruct.Logger))
+	router.Use(middleware.AccessLogMiddleware())
+	router.Use(gin.Recovery())
+	router.Use(middleware.MetricsMiddleware())
+	// before any route, the docs and probes answer cross-origin requests too
+	router.Use(middleware.CORSMiddleware(conf.CORS))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	healthServer.Routes.New(&router.RouterGroup, internalAppStruct.Handler.HealthCheckHandler)
+
+	initOpenAPIRoute(router, buildOpenAPI(conf))
+
+	// anonymous OCR is opt-in
+	if conf.Anonymous.Enabled {
+		initPublicRoute(router, internalAppStruct, conf)
+	}
 
-	router.Use(middleware.JWTAuthMiddleware())
+	initRoute(router, internalAppStruct, conf)
 
-	initRoute(router, setupData.InternalApp)
+	return router
+}
 
-	port := config.GetConfig().Http.Port
-	httpServer := &http.Server{
-		Addr:    ":" + strconv.Itoa(port),
-		Handler: router,
+// ServerHook listens on start, so a busy port fails the startup, and on stop refuses new
+// connections then waits for the in-flight requests until ctx is done
+func ServerHook(httpServer *http.Server) lifecycle.Hook {
+	return lifecycle.Hook{
+		Name: "http",
+		OnStart: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", httpServer.Addr)
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Println("listen:", err)
+				}
+			}()
+			log.Println("Webserver started")
+			return nil
+		},
+		OnStop: httpServer.Shutdown,
 	}
-
-	go func() {
-		// service connections
-		if err := httpServer.ListenAndServe(); err != nil {
-			log.Println("listen:", err)
-		}
-	}()
-	log.Println("Webserver started")
-	return httpServer
 }
 
-func initRoute(router *gin.Engine, internalAppStruct setup.InternalAppStruct) {
-	//apiRouter := router.Group("/v1/api")
-	//ocrServer.Routes.New(apiRouter.Group("/ocr"), internalAppStruct.Handler.OCRHandler)
+func initRoute(router *gin.Engine, internalAppStruct setup.InternalAppStruct, conf config.Config) {
+	// only the api group is authenticated, unknown paths still answer 404
+	apiRouter := router.Group(setup.BaseURL)
+	apiRouter.Use(
+		middleware.APIKeyAuthMiddleware(internalAppStruct.Services.TenantService),
+		middleware.JWTAuthMiddleware(),
+	)
+
+	ocrRouter := apiRouter.Group("/ocr")
+	if conf.RateLimit.Enabled {
+		keyFunc := middleware.GetRateLimitKeyFunc(conf.RateLimit.KeyBy)
+		ocrRouter.Use(
+			middleware.RateLimitMiddleware(internalAppStruct.RateLimiter, func() ratelimit.Limit {
+				conf := config.GetConfig().RateLimit
+				return ratelimit.Limit{Rate: conf.Rate, Burst: conf.Burst}
+			}, keyFunc),
+			middleware.OCRQuotaMiddleware(internalAppStruct.OCRQuota,
+				middleware.TenantPlanQuota(internalAppStruct.Services.TenantService, func() map[string]int {
+					return config.GetConfig().RateLimit.OCRQuotaPlans
+				}),
+				keyFunc),
+		)
+	}
+	ocrServer.Routes.New(ocrRouter, internalAppStruct.Handler.OCRHandler)
 
 }
 
-func initPublicRoute(router *gin.Engine, internalAppStruct setup.InternalAppStruct) {
-	apiRouter := router.Group("/v1/public-api")
-
-	ocrServer.Routes.New(apiRouter.Group("/ocr"), internalAppStruct.Handler.OCRHandler)
+// initPublicRoute registers the anonymous routes, they always run with their own
+// stricter limits and a captcha check
+func initPublicRoute(router *gin.Engine, internalAppStruct setup.InternalAppStruct, conf config.Config) {
+	apiRouter := router.Group(publicBaseURL)
+
+	ocrRouter := apiRouter.Group("/ocr")
+	ocrRouter.Use(
+		middleware.RateLimitMiddleware(internalAppStruct.RateLimiter, func() ratelimit.Limit {
+			conf := config.GetConfig().Anonymous
+			return ratelimit.Limit{Rate: conf.Rate, Burst: conf.Burst}
+		}, middleware.RateLimitKeyAnonymous),
+		middleware.CaptchaMiddleware(internalAppStruct.Captcha),
+		middleware.OCRQuotaMiddleware(internalAppStruct.OCRQuota,
+			middleware.FixedQuota(func() int { return config.GetConfig().Anonymous.MonthlyQuota }),
+			middleware.RateLimitKeyAnonymous),
+	)
+	ocrServer.Routes.New(ocrRouter, internalAppStruct.Handler.AnonymousOCRHandler)
 
 }
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"rest-app/config"
+	"rest-app/internal/setup"
+)
+
+func TestRouterAuthenticatesOnlyTheAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	app := setup.InternalAppStruct{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	app.Handler = setup.InitHandlerApp{
+		HealthCheckHandler:  stubHandler{},
+		OCRHandler:          stubHandler{},
+		AnonymousOCRHandler: stubHandler{},
+	}
+	router := newRouter(config.Config{}, app)
+
+	for _, tc := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/unknown", http.StatusNotFound},
+		{http.MethodGet, setup.BaseURL + "/unknown", http.StatusNotFound},
+		{http.MethodGet, "/healthz", http.StatusOK},
+		{http.MethodPost, setup.BaseURL + "/ocr/receipt", http.StatusUnauthorized},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
-	"fmt"
+	"errors"
 	"log"
+	"rest-app/pkg/constants"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -13,12 +18,154 @@ type (
 		URL      string
 		APIToken string
 		Model    string
+		HTTPLog  HTTPClientLogConf
 	}
 
 	GoogleAIAPIConf struct {
 		URL      string
 		APIToken string
 		Model    string
+		HTTPLog  HTTPClientLogConf
+	}
+
+	// HTTPLogConf redaction rules shared by every outbound http client
+	HTTPLogConf struct {
+		RedactHeaders     []string
+		RedactQueryParams []string
+		MaxBodyBytes      int // 0 disables body logging
+	}
+
+	// HTTPClientConf retry and circuit breaker policy of outbound http clients
+	HTTPClientConf struct {
+		RetryMaxAttempts        int
+		RetryBaseDelay          time.Duration
+		RetryMaxDelay           time.Duration
+		BreakerFailureThreshold int // 0 disables the breaker
+		BreakerOpenFor          time.Duration
+		CassetteMode            string // off, record or replay
+		CassetteDir             string // one <client>.json cassette per client
+	}
+
+	// HTTPClientLogConf per client overrides of HTTPLogConf
+	HTTPClientLogConf struct {
+		Level           string   // debug, info, warn or error
+		RedactBodyPaths []string // dot separated JSON paths, * matches any key or index
+	}
+
+	TenantConf struct {
+		DefaultLLMProvider   string
+		DefaultDocumentTypes []string
+		DefaultPlan          string
+	}
+
+	RateLimitConf struct {
+		Enabled bool
+		Backend string // memory, the only backend until the app cache is shared by the replicas
+		KeyBy   string // auto, ip, user or api_key
+		Rate    float64
+		Burst   int
+		// OCRQuotaPlans monthly OCR requests per plan, negative means unlimited
+		OCRQuotaPlans map[string]int
+	}
+
+	// AnonymousConf opt-in unauthenticated OCR, results are never persisted
+	AnonymousConf struct {
+		Enabled          bool
+		Rate             float64
+		Burst            int
+		MonthlyQuota     int // per client ip
+		CaptchaVerifyURL string
+		CaptchaSecret    string
+	}
+
+	OCRConf struct {
+		PoolSize       int
+		MaxUploadBytes int64
+		// AllowedImageFormats jpeg, png, webp, tiff and heic, detected from the file content. heic
+		// needs a HEIF decoder registered with image.RegisterFormat, none is linked by default
+		AllowedImageFormats []string
+		MaxImageWidth       int
+		MaxImageHeight      int
+		MaxImagePixels      int64
+		// MaxImagePixelsByFormat overrides MaxImagePixels for formats decoded in Go memory
+		MaxImagePixelsByFormat map[string]int
+		// RepairEnabled a receipt breaking validation rules is sent back once to the LLM to correct
+		RepairEnabled bool
+		// DefaultTimezone IANA name of the timezone of receipts printing none
+		DefaultTimezone string
+		// DefaultCurrency ISO 4217 code of receipts printing no currency
+		DefaultCurrency string
+		// LogoDir holds the logo templates banks and e-wallets are detected by when their name isn't
+		// in the text, empty turns logo detection off
+		LogoDir string
+		// LogoMinScore correlation, from 0 to 1, a logo must reach to be detected
+		LogoMinScore float64
+		Preprocess   PreprocessConf
+	}
+
+	// PreprocessConf OpenCV steps run on a receipt before tesseract, kernel sizes are in pixels
+	PreprocessConf struct {
+		// MaxDimension larger images are scaled down so their longest side fits
+		MaxDimension int
+		// BlurKernel Gaussian blur kernel, odd
+		BlurKernel int
+		// ThresholdBlockSize neighbourhood of the adaptive threshold, odd and at least 3
+		ThresholdBlockSize int
+		// ThresholdC subtracted from the neighbourhood mean
+		ThresholdC float64
+		// MorphKernel rectangle of the opening that removes specks
+		MorphKernel int
+		// MedianKernel median blur applied last, odd and at least 3
+		MedianKernel int
+	}
+
+	// PromptConf Versions pins the prompt template versions of a document type ("receipt") or of a
+	// document type and provider ("receipt.huggingface"), several versions split the requests evenly
+	// between them. Unpinned pairs use their latest version
+	PromptConf struct {
+		Versions map[string][]string
+	}
+
+	// FewShotConf examples of the library added to extraction prompts, MaxExamples 0 disables them
+	FewShotConf struct {
+		MaxExamples int
+		// MinSimilarity word overlap, from 0 to 1, an example needs to be picked when its bank isn't in the text
+		MinSimilarity float64
+		// RefreshInterval how often the library is read again from the database
+		RefreshInterval time.Duration
+	}
+
+	HealthConf struct {
+		Timeout          time.Duration
+		LLMCheckInterval time.Duration
+	}
+
+	// CORSConf browser cross origin policy, origins may contain * wildcards (https://*.example.com, http://localhost:*)
+	CORSConf struct {
+		AllowedOrigins   []string // a single * allows any origin, credentials are then never allowed
+		AllowedMethods   []string
+		AllowedHeaders   []string // a single * allows whatever the preflight asks for
+		ExposedHeaders   []string
+		AllowCredentials bool
+		MaxAge           time.Duration
+	}
+
+	// ShutdownConf Timeout bounds the whole shutdown, in-flight requests included
+	ShutdownConf struct {
+		Timeout time.Duration
+	}
+
+	LogConf struct {
+		Level  string // debug, info, warn or error
+		Format string // json or text
+	}
+
+	TracingConf struct {
+		Exporter     string // none, otlp, stdout or file
+		OTLPEndpoint string
+		OTLPInsecure bool
+		FilePath     string
+		SampleRatio  float64
 	}
 
 	DB struct {
@@ -51,90 +198,325 @@ type (
 		JWT                jwt
 		HuggingFaceAPIConf HuggingFaceAPIConf
 		GoogleAIAPIConf    GoogleAIAPIConf
+		Tenant             TenantConf
+		RateLimit          RateLimitConf
+		Anonymous          AnonymousConf
+		OCR                OCRConf
+		Prompt             PromptConf
+		FewShot            FewShotConf
+		Health             HealthConf
+		Tracing            TracingConf
+		Log                LogConf
+		HTTPLog            HTTPLogConf
+		HTTPClient         HTTPClientConf
+		CORS               CORSConf
+		Shutdown           ShutdownConf
 	}
 )
 
-var (
-	configData *Config
-)
+// snapshot a loaded configuration with the raw value of every key it was built from
+type snapshot struct {
+	conf *Config
+	keys []loadedKey
+}
+
+// current the configuration in use, replaced as a whole on reload
+var current atomic.Pointer[snapshot]
 
+// InitConfig loads the configuration and stops the process listing every problem found
 func InitConfig() {
-	viper.SetConfigType("env")
-	viper.SetConfigName(".env") // name of Config file (without extension)
-	viper.AddConfigPath(".")
-	viper.AddConfigPath("/secrets")
-	viper.AutomaticEnv()
-
-	if err := viper.ReadInConfig(); err != nil {
-		logrus.WithError(err).Warn("failed to load config file")
-	}
-
-	configData = &Config{
-		// DB: DB{
-		// 	DSN:             getRequiredString("DB_DSN"),
-		// 	DSNPool:         getRequiredString("DB_POOL_DSN"),
-		// 	MaxOpenConn:     getRequiredInt("DB_MAX_OPEN_CONN"),
-		// 	MaxIdleConn:     getRequiredInt("DB_MAX_IDLE_CONN"),
-		// 	MaxLifetimeConn: getRequiredInt("DB_MAX_LIFETIME_CONN"),
-		// 	MaxIdletimeConn: getRequiredInt("DB_MAX_IDLETIME_CONN"),
-		// },
-		// App: app{
-		// 	Env:     getRequiredString("APP_ENV"),
-		// 	Version: viper.GetString("BITBUCKET_TAG"),
-		// 	Name:    "rest-app",
-		// },
-		Http: http{
-			Port: getRequiredInt("APP_PORT"),
-		},
-		// JWT: jwt{
-		// 	SigningKey: getRequiredString("SIGNING_KEY"),
-		// },
-		// HuggingFaceAPIConf: HuggingFaceAPIConf{
-		// 	URL:      getRequiredString("HUGGINGFACE_API_URL"),
-		// 	Model:    getRequiredString("HUGGINGFACE_API_MODEL"),
-		// 	APIToken: getRequiredString("HUGGINGFACE_API_TOKEN"),
-		// },
-		GoogleAIAPIConf: GoogleAIAPIConf{
-			URL:      getRequiredString("GOOGLE_AI_API_URL"),
-			Model:    getRequiredString("GOOGLE_AI_API_MODEL"),
-			APIToken: getRequiredString("GOOGLE_AI_API_TOKEN"),
-		},
+	snap, err := load()
+	if err != nil {
+		log.Fatalln(err)
 	}
+	current.Store(snap)
 }
 
-func getRequiredString(key string) string {
-	if viper.IsSet(key) {
-		return viper.GetString(key)
+// Load reads the configuration from .env, config.yaml (or .toml), its config.<APP_ENV> overlay and
+// the environment, in increasing precedence. The returned config is filled in even when the error,
+// a *ValidationError, reports missing or invalid keys
+func Load() (*Config, error) {
+	snap, err := load()
+	current.Store(snap)
+	return snap.conf, err
+}
+
+// load reads a new snapshot into a viper instance of its own, nothing shared changes until the
+// caller stores the snapshot
+func load() (*snapshot, error) {
+	v := viper.New()
+	v.AutomaticEnv()
+
+	v.SetDefault("APP_ENV", constants.PRODUCTION)
+	v.SetDefault("DB_MAX_OPEN_CONN", 100)
+	v.SetDefault("DB_MAX_IDLE_CONN", 10)
+	v.SetDefault("DB_MAX_LIFETIME_CONN", 4)
+	v.SetDefault("DB_MAX_IDLETIME_CONN", 1)
+	v.SetDefault("TENANT_DEFAULT_LLM_PROVIDER", constants.LLM_PROVIDER_GOOGLEAI)
+	v.SetDefault("TENANT_DEFAULT_DOCUMENT_TYPES", constants.DOCUMENT_TYPE_RECEIPT)
+	v.SetDefault("TENANT_DEFAULT_PLAN", "free")
+	v.SetDefault("RATE_LIMIT_ENABLED", true)
+	v.SetDefault("RATE_LIMIT_BACKEND", "memory")
+	v.SetDefault("RATE_LIMIT_KEY_BY", "auto")
+	v.SetDefault("RATE_LIMIT_RATE", 1)
+	v.SetDefault("RATE_LIMIT_BURST", 5)
+	v.SetDefault("OCR_QUOTA_PLANS", "free:100,pro:5000,enterprise:-1")
+	v.SetDefault("OCR_ANONYMOUS_ENABLED", false)
+	v.SetDefault("OCR_ANONYMOUS_RATE_LIMIT_RATE", 0.1)
+	v.SetDefault("OCR_ANONYMOUS_RATE_LIMIT_BURST", 2)
+	v.SetDefault("OCR_ANONYMOUS_MONTHLY_QUOTA", 20)
+	v.SetDefault("OCR_POOL_SIZE", constants.MAX_GOROUTINES)
+	v.SetDefault("OCR_MAX_UPLOAD_BYTES", 5<<20)
+	v.SetDefault("OCR_ALLOWED_IMAGE_FORMATS", "jpeg,png,webp,tiff")
+	v.SetDefault("OCR_MAX_IMAGE_WIDTH", 10000)
+	v.SetDefault("OCR_MAX_IMAGE_HEIGHT", 10000)
+	v.SetDefault("OCR_MAX_IMAGE_PIXELS", 40_000_000)
+	v.SetDefault("OCR_MAX_IMAGE_PIXELS_BY_FORMAT", "webp:25000000,tiff:25000000")
+	v.SetDefault("OCR_REPAIR_ENABLED", true)
+	v.SetDefault("OCR_DEFAULT_TIMEZONE", "Asia/Jakarta")
+	v.SetDefault("OCR_DEFAULT_CURRENCY", "IDR")
+	v.SetDefault("OCR_LOGO_DIR", "")
+	v.SetDefault("OCR_LOGO_MIN_SCORE", 0.8)
+	v.SetDefault("OCR_PREPROCESS_MAX_DIMENSION", 2000)
+	v.SetDefault("OCR_PREPROCESS_BLUR_KERNEL", 3)
+	v.SetDefault("OCR_PREPROCESS_THRESHOLD_BLOCK_SIZE", 11)
+	v.SetDefault("OCR_PREPROCESS_THRESHOLD_C", 2)
+	v.SetDefault("OCR_PREPROCESS_MORPH_KERNEL", 2)
+	v.SetDefault("OCR_PREPROCESS_MEDIAN_KERNEL", 3)
+	v.SetDefault("FEW_SHOT_MAX_EXAMPLES", 2)
+	v.SetDefault("FEW_SHOT_MIN_SIMILARITY", 0.3)
+	v.SetDefault("FEW_SHOT_REFRESH_INTERVAL", "1m")
+	v.SetDefault("HEALTH_CHECK_TIMEOUT", "2s")
+	v.SetDefault("HEALTH_LLM_CHECK_INTERVAL", "1m")
+	v.SetDefault("SHUTDOWN_TIMEOUT", "30s")
+	v.SetDefault("LOG_LEVEL", "info")
+	v.SetDefault("LOG_FORMAT", "json")
+	v.SetDefault("HTTP_LOG_REDACT_HEADERS", "Authorization,Proxy-Authorization,Cookie,Set-Cookie,X-Api-Key,X-Goog-Api-Key")
+	v.SetDefault("HTTP_LOG_REDACT_QUERY_PARAMS", "key,api_key,apikey,token,access_token,secret")
+	v.SetDefault("HTTP_LOG_MAX_BODY_BYTES", 2048)
+	v.SetDefault("HTTP_CLIENT_RETRY_MAX_ATTEMPTS", 3)
+	v.SetDefault("HTTP_CLIENT_RETRY_BASE_DELAY", "500ms")
+	v.SetDefault("HTTP_CLIENT_RETRY_MAX_DELAY", "10s")
+	v.SetDefault("HTTP_CLIENT_BREAKER_FAILURE_THRESHOLD", 5)
+	v.SetDefault("HTTP_CLIENT_BREAKER_OPEN_FOR", "30s")
+	v.SetDefault("HTTP_CASSETTE_MODE", "off")
+	v.SetDefault("HTTP_CASSETTE_DIR", "testdata/cassettes")
+	v.SetDefault("GOOGLE_AI_HTTP_LOG_LEVEL", "info")
+	v.SetDefault("GOOGLE_AI_HTTP_LOG_REDACT_BODY_PATHS", "candidates.*.content.parts.*.text")
+	v.SetDefault("HUGGINGFACE_HTTP_LOG_LEVEL", "info")
+	v.SetDefault("HUGGINGFACE_HTTP_LOG_REDACT_BODY_PATHS", "*.generated_text")
+	v.SetDefault("TRACING_EXPORTER", "none")
+	v.SetDefault("TRACING_FILE_PATH", "traces.json")
+	v.SetDefault("TRACING_SAMPLE_RATIO", 1)
+
+	files, err := readFiles(v)
+	if len(files) == 0 && err == nil {
+		logrus.Warn("no config file found, reading the environment only")
 	}
 
-	log.Fatalln(fmt.Errorf("KEY %s IS MISSING", key))
-	return ""
+	snap, buildErr := build(v)
+	if err != nil {
+		// a file that can't be parsed is a problem like any other, the rest is still reported
+		problems := []Problem{{Key: "config file", Reason: err.Error()}}
+		var validationErr *ValidationError
+		if errors.As(buildErr, &validationErr) {
+			problems = append(problems, validationErr.Problems...)
+		}
+		return snap, &ValidationError{Problems: problems}
+	}
+	return snap, buildErr
 }
 
-func getRequiredInt(key string) int {
-	if viper.IsSet(key) {
-		return viper.GetInt(key)
+// build makes a snapshot of what v holds
+func build(v *viper.Viper) (*snapshot, error) {
+	// defaults depending on the environment can only be set once APP_ENV is known
+	setEnvDefaults(v, v.GetString("APP_ENV"))
+
+	l := &loader{v: v}
+	conf := &Config{
+		// DB is optional for now, tenant settings and receipts are only persisted when DB_DSN is set
+		DB: DB{
+			DSN:             l.getSecret("DB_DSN"),
+			DSNPool:         l.getSecret("DB_POOL_DSN"),
+			MaxOpenConn:     l.getInt("DB_MAX_OPEN_CONN"),
+			MaxIdleConn:     l.getInt("DB_MAX_IDLE_CONN"),
+			MaxLifetimeConn: l.getInt("DB_MAX_LIFETIME_CONN"),
+			MaxIdletimeConn: l.getInt("DB_MAX_IDLETIME_CONN"),
+		},
+		App: app{
+			Env:     l.getString("APP_ENV"),
+			Version: l.getString("BITBUCKET_TAG"),
+			Name:    "rest-app",
+		},
+		Http: http{
+			Port: l.getInt("APP_PORT"),
+		},
+		JWT: jwt{
+			SigningKey: l.getSecret("SIGNING_KEY"),
+		},
+		// Hugging Face is an optional per tenant LLM provider
+		HuggingFaceAPIConf: HuggingFaceAPIConf{
+			URL:      l.getString("HUGGINGFACE_API_URL"),
+			Model:    l.getString("HUGGINGFACE_API_MODEL"),
+			APIToken: l.getSecret("HUGGINGFACE_API_TOKEN"),
+			HTTPLog: HTTPClientLogConf{
+				Level:           l.getString("HUGGINGFACE_HTTP_LOG_LEVEL"),
+				RedactBodyPaths: l.getStringSlice("HUGGINGFACE_HTTP_LOG_REDACT_BODY_PATHS"),
+			},
+		},
+		GoogleAIAPIConf: GoogleAIAPIConf{
+			URL:      l.getString("GOOGLE_AI_API_URL"),
+			Model:    l.getString("GOOGLE_AI_API_MODEL"),
+			APIToken: l.getSecret("GOOGLE_AI_API_TOKEN"),
+			HTTPLog: HTTPClientLogConf{
+				Level:           l.getString("GOOGLE_AI_HTTP_LOG_LEVEL"),
+				RedactBodyPaths: l.getStringSlice("GOOGLE_AI_HTTP_LOG_REDACT_BODY_PATHS"),
+			},
+		},
+		Tenant: TenantConf{
+			DefaultLLMProvider:   l.getString("TENANT_DEFAULT_LLM_PROVIDER"),
+			DefaultDocumentTypes: l.getStringSlice("TENANT_DEFAULT_DOCUMENT_TYPES"),
+			DefaultPlan:          l.getString("TENANT_DEFAULT_PLAN"),
+		},
+		RateLimit: RateLimitConf{
+			Enabled:       l.getBool("RATE_LIMIT_ENABLED"),
+			Backend:       l.getString("RATE_LIMIT_BACKEND"),
+			KeyBy:         l.getString("RATE_LIMIT_KEY_BY"),
+			Rate:          l.getFloat64("RATE_LIMIT_RATE"),
+			Burst:         l.getInt("RATE_LIMIT_BURST"),
+			OCRQuotaPlans: l.getIntMap("OCR_QUOTA_PLANS"),
+		},
+		Anonymous: AnonymousConf{
+			Enabled:          l.getBool("OCR_ANONYMOUS_ENABLED"),
+			Rate:             l.getFloat64("OCR_ANONYMOUS_RATE_LIMIT_RATE"),
+			Burst:            l.getInt("OCR_ANONYMOUS_RATE_LIMIT_BURST"),
+			MonthlyQuota:     l.getInt("OCR_ANONYMOUS_MONTHLY_QUOTA"),
+			CaptchaVerifyURL: l.getString("CAPTCHA_VERIFY_URL"),
+			CaptchaSecret:    l.getSecret("CAPTCHA_SECRET"),
+		},
+		OCR: OCRConf{
+			PoolSize:               l.getInt("OCR_POOL_SIZE"),
+			MaxUploadBytes:         l.getInt64("OCR_MAX_UPLOAD_BYTES"),
+			AllowedImageFormats:    l.getStringSlice("OCR_ALLOWED_IMAGE_FORMATS"),
+			MaxImageWidth:          l.getInt("OCR_MAX_IMAGE_WIDTH"),
+			MaxImageHeight:         l.getInt("OCR_MAX_IMAGE_HEIGHT"),
+			MaxImagePixels:         l.getInt64("OCR_MAX_IMAGE_PIXELS"),
+			MaxImagePixelsByFormat: l.getIntMap("OCR_MAX_IMAGE_PIXELS_BY_FORMAT"),
+			RepairEnabled:          l.getBool("OCR_REPAIR_ENABLED"),
+			DefaultTimezone:        l.getString("OCR_DEFAULT_TIMEZONE"),
+			DefaultCurrency:        l.getString("OCR_DEFAULT_CURRENCY"),
+			LogoDir:                l.getString("OCR_LOGO_DIR"),
+			LogoMinScore:           l.getFloat64("OCR_LOGO_MIN_SCORE"),
+			Preprocess: PreprocessConf{
+				MaxDimension:       l.getInt("OCR_PREPROCESS_MAX_DIMENSION"),
+				BlurKernel:         l.getInt("OCR_PREPROCESS_BLUR_KERNEL"),
+				ThresholdBlockSize: l.getInt("OCR_PREPROCESS_THRESHOLD_BLOCK_SIZE"),
+				ThresholdC:         l.getFloat64("OCR_PREPROCESS_THRESHOLD_C"),
+				MorphKernel:        l.getInt("OCR_PREPROCESS_MORPH_KERNEL"),
+				MedianKernel:       l.getInt("OCR_PREPROCESS_MEDIAN_KERNEL"),
+			},
+		},
+		Prompt: PromptConf{
+			Versions: promptVersions(l.getStringMap("PROMPT_VERSIONS")),
+		},
+		FewShot: FewShotConf{
+			MaxExamples:     l.getInt("FEW_SHOT_MAX_EXAMPLES"),
+			MinSimilarity:   l.getFloat64("FEW_SHOT_MIN_SIMILARITY"),
+			RefreshInterval: l.getDuration("FEW_SHOT_REFRESH_INTERVAL"),
+		},
+		Health: HealthConf{
+			Timeout:          l.getDuration("HEALTH_CHECK_TIMEOUT"),
+			LLMCheckInterval: l.getDuration("HEALTH_LLM_CHECK_INTERVAL"),
+		},
+		Tracing: TracingConf{
+			Exporter:     l.getString("TRACING_EXPORTER"),
+			OTLPEndpoint: l.getString("TRACING_OTLP_ENDPOINT"),
+			OTLPInsecure: l.getBool("TRACING_OTLP_INSECURE"),
+			FilePath:     l.getString("TRACING_FILE_PATH"),
+			SampleRatio:  l.getFloat64("TRACING_SAMPLE_RATIO"),
+		},
+		Log: LogConf{
+			Level:  l.getString("LOG_LEVEL"),
+			Format: l.getString("LOG_FORMAT"),
+		},
+		CORS: CORSConf{
+			AllowedOrigins:   l.getStringSlice("CORS_ALLOWED_ORIGINS"),
+			AllowedMethods:   l.getStringSlice("CORS_ALLOWED_METHODS"),
+			AllowedHeaders:   l.getStringSlice("CORS_ALLOWED_HEADERS"),
+			ExposedHeaders:   l.getStringSlice("CORS_EXPOSED_HEADERS"),
+			AllowCredentials: l.getBool("CORS_ALLOW_CREDENTIALS"),
+			MaxAge:           l.getDuration("CORS_MAX_AGE"),
+		},
+		Shutdown: ShutdownConf{
+			Timeout: l.getDuration("SHUTDOWN_TIMEOUT"),
+		},
+		HTTPLog: HTTPLogConf{
+			RedactHeaders:     l.getStringSlice("HTTP_LOG_REDACT_HEADERS"),
+			RedactQueryParams: l.getStringSlice("HTTP_LOG_REDACT_QUERY_PARAMS"),
+			MaxBodyBytes:      l.getInt("HTTP_LOG_MAX_BODY_BYTES"),
+		},
+		HTTPClient: HTTPClientConf{
+			RetryMaxAttempts:        l.getInt("HTTP_CLIENT_RETRY_MAX_ATTEMPTS"),
+			RetryBaseDelay:          l.getDuration("HTTP_CLIENT_RETRY_BASE_DELAY"),
+			RetryMaxDelay:           l.getDuration("HTTP_CLIENT_RETRY_MAX_DELAY"),
+			BreakerFailureThreshold: l.getInt("HTTP_CLIENT_BREAKER_FAILURE_THRESHOLD"),
+			BreakerOpenFor:          l.getDuration("HTTP_CLIENT_BREAKER_OPEN_FOR"),
+			CassetteMode:            l.getString("HTTP_CASSETTE_MODE"),
+			CassetteDir:             l.getString("HTTP_CASSETTE_DIR"),
+		},
+	}
+
+	// the pool connects to the same database unless told otherwise
+	if conf.DB.DSNPool == "" {
+		conf.DB.DSNPool = conf.DB.DSN
 	}
 
-	panic(fmt.Errorf("KEY %s IS MISSING", key))
-}
+	snap := &snapshot{conf: conf, keys: l.keys}
 
-// func getRequiredBool(key string) bool {
-// 	if viper.IsSet(key) {
-// 		return viper.GetBool(key)
-// 	}
+	problems := l.problems
+	var validationErr *ValidationError
+	if err := conf.Validate(); errors.As(err, &validationErr) {
+		// a value that could not be parsed is reported once, not again for its zero value
+		for _, p := range validationErr.Problems {
+			if !slices.ContainsFunc(l.problems, func(lp Problem) bool { return lp.Key == p.Key }) {
+				problems = append(problems, p)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return snap, &ValidationError{Problems: problems}
+	}
+	return snap, nil
+}
 
-// 	panic(fmt.Errorf("KEY %s IS MISSING", key))
-// }
+// promptVersions splits the | separated versions of every prompt
+func promptVersions(pairs map[string]string) map[string][]string {
+	versions := make(map[string][]string, len(pairs))
+	for name, value := range pairs {
+		for _, version := range strings.Split(value, "|") {
+			versions[name] = append(versions[name], strings.TrimSpace(version))
+		}
+	}
+	return versions
+}
 
-// func getRequiredDuration(key string) time.Duration {
-// 	if viper.IsSet(key) {
-// 		return viper.GetDuration(key)
-// 	}
+// setEnvDefaults production allows no cross origin browser calls unless configured, other environments allow local front ends
+func setEnvDefaults(v *viper.Viper, env string) {
+	v.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE")
+	v.SetDefault("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-API-Key,X-Captcha-Token,X-Request-ID,X-App-Id,X-Client-Id,X-Client-Version,X-Mock-Data")
+	v.SetDefault("CORS_EXPOSED_HEADERS", "Content-Length,X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After")
+	v.SetDefault("CORS_ALLOW_CREDENTIALS", true)
 
-// 	panic(fmt.Errorf("KEY %s IS MISSING", key))
-// }
+	if env == constants.PRODUCTION {
+		v.SetDefault("CORS_ALLOWED_ORIGINS", "")
+		v.SetDefault("CORS_MAX_AGE", "1h")
+	} else {
+		v.SetDefault("CORS_ALLOWED_ORIGINS", "http://localhost:*,http://127.0.0.1:*")
+		v.SetDefault("CORS_MAX_AGE", "24h")
+	}
+}
 
+// GetConfig returns a copy of the configuration in use, call it again to see reloaded values
 func GetConfig() Config {
-	return *configData
+	return *current.Load().conf
 }
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// configPaths directories searched for config files, a file in the first one wins
+var configPaths = []string{".", "/secrets"}
+
+// configExtensions formats of the base and overlay files, in lookup order
+var configExtensions = []string{"yaml", "yml", "toml"}
+
+// fileRefPrefix marks a value read from a file, e.g. file:///secrets/google_ai_api_token
+const fileRefPrefix = "file://"
+
+// readFiles replaces the config layer of v with, from lowest to highest precedence, .env,
+// config.<ext> and the config.<APP_ENV>.<ext> overlay. Environment variables still win over all of them.
+// It returns the files read
+func readFiles(v *viper.Viper) ([]string, error) {
+	merged := map[string]interface{}{}
+	var files []string
+
+	read := func(path, configType string) error {
+		file := viper.New()
+		file.SetConfigFile(path)
+		file.SetConfigType(configType)
+		if err := file.ReadInConfig(); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		flatten(merged, "", file.AllSettings())
+		files = append(files, path)
+		return nil
+	}
+
+	if path := findFile(".env"); path != "" {
+		if err := read(path, "env"); err != nil {
+			return files, err
+		}
+	}
+	if path, ext := findConfigFile("config"); path != "" {
+		if err := read(path, ext); err != nil {
+			return files, err
+		}
+	}
+
+	// the overlay is picked by APP_ENV as known so far, from the environment or the files above
+	if err := setFileLayer(v, merged); err != nil {
+		return files, err
+	}
+	if path, ext := findConfigFile("config." + v.GetString("APP_ENV")); path != "" {
+		if err := read(path, ext); err != nil {
+			return files, err
+		}
+	}
+
+	return files, setFileLayer(v, merged)
+}
+
+// setFileLayer swaps the config values of v for values, defaults and environment variables are kept
+func setFileLayer(v *viper.Viper, values map[string]interface{}) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	v.SetConfigType("json")
+	return v.ReadConfig(bytes.NewReader(data))
+}
+
+// flatten joins nested keys with _ so google_ai: {api_token: x} reads as GOOGLE_AI_API_TOKEN,
+// lists become comma separated values
+func flatten(dst map[string]interface{}, prefix string, src map[string]interface{}) {
+	for key, value := range src {
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			flatten(dst, key, value)
+		case []interface{}:
+			dst[key] = strings.Join(cast.ToStringSlice(value), ",")
+		default:
+			dst[key] = value
+		}
+	}
+}
+
+func findFile(name string) string {
+	for _, dir := range configPaths {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// findConfigFile looks for name with one of configExtensions, returns its path and format
+func findConfigFile(name string) (string, string) {
+	for _, dir := range configPaths {
+		for _, ext := range configExtensions {
+			path := filepath.Join(dir, name+"."+ext)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				if ext == "yml" {
+					ext = "yaml"
+				}
+				return path, ext
+			}
+		}
+	}
+	return "", ""
+}
+
+// isConfigFile tells whether a file named name is read by readFiles when APP_ENV is env
+func isConfigFile(name, env string) bool {
+	if name == ".env" {
+		return true
+	}
+	for _, ext := range configExtensions {
+		if name == "config."+ext || name == "config."+env+"."+ext {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveFileRef returns the content of the file a file:// value points to, without its trailing
+// new lines, any other value is returned as is
+func resolveFileRef(value string) (string, error) {
+	if !strings.HasPrefix(value, fileRefPrefix) {
+		return value, nil
+	}
+
+	ref, err := url.Parse(value)
+	if err != nil || ref.Host != "" || ref.Path == "" {
+		return "", errors.New("file reference must look like file:///path/to/file")
+	}
+
+	content, err := os.ReadFile(ref.Path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %w", ref.Path, errors.Unwrap(err))
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// loader reads typed keys from viper, it records malformed values instead of stopping at the first one
+// and remembers every key it read so the effective configuration can be printed
+type loader struct {
+	v        *viper.Viper
+	problems []Problem
+	keys     []loadedKey
+}
+
+type loadedKey struct {
+	name   string
+	value  string
+	secret bool
+}
+
+// read resolves file:// references, an unreadable file is reported and read as empty
+func (l *loader) read(key string, secret bool) interface{} {
+	value := l.v.Get(key)
+	if s, ok := value.(string); ok {
+		resolved, err := resolveFileRef(s)
+		if err != nil {
+			l.invalid(key, err)
+		}
+		value = resolved
+	}
+	l.keys = append(l.keys, loadedKey{name: key, value: cast.ToString(value), secret: secret})
+	return value
+}
+
+func (l *loader) invalid(key string, err error) {
+	l.problems = append(l.problems, Problem{Key: key, Reason: err.Error()})
+}
+
+func (l *loader) getString(key string) string {
+	return cast.ToString(l.read(key, false))
+}
+
+// getSecret is getString for values that must never be printed
+func (l *loader) getSecret(key string) string {
+	return cast.ToString(l.read(key, true))
+}
+
+func (l *loader) getInt(key string) int {
+	n, err := cast.ToIntE(l.read(key, false))
+	if err != nil {
+		l.invalid(key, fmt.Errorf("not an integer"))
+	}
+	return n
+}
+
+func (l *loader) getInt64(key string) int64 {
+	n, err := cast.ToInt64E(l.read(key, false))
+	if err != nil {
+		l.invalid(key, fmt.Errorf("not an integer"))
+	}
+	return n
+}
+
+func (l *loader) getFloat64(key string) float64 {
+	f, err := cast.ToFloat64E(l.read(key, false))
+	if err != nil {
+		l.invalid(key, fmt.Errorf("not a number"))
+	}
+	return f
+}
+
+func (l *loader) getBool(key string) bool {
+	value := l.read(key, false)
+	if s, ok := value.(string); ok && s == "" {
+		return false
+	}
+	b, err := cast.ToBoolE(value)
+	if err != nil {
+		l.invalid(key, fmt.Errorf("not a boolean"))
+	}
+	return b
+}
+
+func (l *loader) getDuration(key string) time.Duration {
+	d, err := cast.ToDurationE(l.read(key, false))
+	if err != nil {
+		l.invalid(key, fmt.Errorf("not a duration (ex: 500ms, 2s, 1h)"))
+	}
+	return d
+}
+
+// getStringSlice reads a comma separated list
+func (l *loader) getStringSlice(key string) []string {
+	var values []string
+	for _, v := range strings.Split(cast.ToString(l.read(key, false)), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// getStringMap reads a comma separated list of name:value pairs
+func (l *loader) getStringMap(key string) map[string]string {
+	values := map[string]string{}
+	for _, pair := range l.getStringSlice(key) {
+		name, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			l.invalid(key, fmt.Errorf("%q is not a name:value pair", pair))
+			continue
+		}
+		values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+// getIntMap reads a comma separated list of name:int pairs
+func (l *loader) getIntMap(key string) map[string]int {
+	values := map[string]int{}
+	for _, pair := range l.getStringSlice(key) {
+		name, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			l.invalid(key, fmt.Errorf("%q is not a name:int pair", pair))
+			continue
+		}
+		n, err := cast.ToIntE(strings.TrimSpace(value))
+		if err != nil {
+			l.invalid(key, fmt.Errorf("%q is not a name:int pair", pair))
+			continue
+		}
+		values[strings.TrimSpace(name)] = n
+	}
+	return values
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Print writes every key of the configuration in use as KEY=value, sorted by key. With redacted,
+// values of secrets (tokens, signing key, DSNs) are replaced, an unset secret stays empty
+// so a missing one is still visible
+func Print(w io.Writer, redacted bool) error {
+	snap := current.Load()
+	if snap == nil {
+		return nil
+	}
+	keys := make([]loadedKey, len(snap.keys))
+	copy(keys, snap.keys)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].name < keys[j].name })
+
+	for _, key := range keys {
+		value := key.value
+		if redacted && key.secret && value != "" {
+			value = "[REDACTED]"
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key.name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
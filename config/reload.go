@@ -0,0 +1,169 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// liveKeys settings applied without a restart, a change to any other key rejects the whole reload
+var liveKeys = map[string]bool{
+	"GOOGLE_AI_API_MODEL":                 true,
+	"HUGGINGFACE_API_MODEL":               true,
+	"RATE_LIMIT_RATE":                     true,
+	"RATE_LIMIT_BURST":                    true,
+	"OCR_QUOTA_PLANS":                     true,
+	"OCR_ANONYMOUS_RATE_LIMIT_RATE":       true,
+	"OCR_ANONYMOUS_RATE_LIMIT_BURST":      true,
+	"OCR_ANONYMOUS_MONTHLY_QUOTA":         true,
+	"OCR_ALLOWED_IMAGE_FORMATS":           true,
+	"OCR_MAX_IMAGE_WIDTH":                 true,
+	"OCR_MAX_IMAGE_HEIGHT":                true,
+	"OCR_MAX_IMAGE_PIXELS":                true,
+	"OCR_MAX_IMAGE_PIXELS_BY_FORMAT":      true,
+	"OCR_REPAIR_ENABLED":                  true,
+	"OCR_DEFAULT_TIMEZONE":                true,
+	"OCR_DEFAULT_CURRENCY":                true,
+	"OCR_LOGO_MIN_SCORE":                  true,
+	"OCR_PREPROCESS_MAX_DIMENSION":        true,
+	"OCR_PREPROCESS_BLUR_KERNEL":          true,
+	"OCR_PREPROCESS_THRESHOLD_BLOCK_SIZE": true,
+	"OCR_PREPROCESS_THRESHOLD_C":          true,
+	"OCR_PREPROCESS_MORPH_KERNEL":         true,
+	"OCR_PREPROCESS_MEDIAN_KERNEL":        true,
+	"PROMPT_VERSIONS":                     true,
+	"FEW_SHOT_MAX_EXAMPLES":               true,
+	"FEW_SHOT_MIN_SIMILARITY":             true,
+	"CORS_ALLOWED_ORIGINS":                true,
+	"CORS_ALLOWED_METHODS":                true,
+	"CORS_ALLOWED_HEADERS":                true,
+	"CORS_EXPOSED_HEADERS":                true,
+	"CORS_ALLOW_CREDENTIALS":              true,
+	"CORS_MAX_AGE":                        true,
+}
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   []func(Config)
+)
+
+// Subscribe registers fn to be called with the new configuration after every accepted reload
+func Subscribe(fn func(Config)) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+// reloadDelay lets editors finish writing a file before it is read
+const reloadDelay = 200 * time.Millisecond
+
+// Watch reloads the configuration whenever one of its files changes. Environment variables
+// still win over the files and can't change while the process runs
+func Watch() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Error("configuration reload disabled", slog.Any("error", err))
+		return
+	}
+
+	// directories are watched rather than files, editors and mounted secrets replace files instead of writing them
+	for _, dir := range configPaths {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			if err := watcher.Add(dir); err != nil {
+				slog.Warn("cannot watch config directory", slog.String("dir", dir), slog.Any("error", err))
+			}
+		}
+	}
+
+	go func() {
+		var (
+			mu    sync.Mutex
+			timer *time.Timer
+		)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !isConfigFile(filepath.Base(event.Name), GetConfig().App.Env) {
+					continue
+				}
+				mu.Lock()
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, reload)
+				mu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("config watcher failed", slog.Any("error", err))
+			}
+		}
+	}()
+}
+
+var reloadMu sync.Mutex
+
+// reload swaps in the configuration read from the files when it is valid and only live keys
+// changed. The files are read into a new snapshot, a rejected one changes nothing
+func reload() {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	snap, err := load()
+	if err != nil {
+		slog.Error("configuration reload rejected", slog.Any("error", err))
+		return
+	}
+
+	changed := changedKeys(current.Load(), snap)
+	if len(changed) == 0 {
+		return
+	}
+
+	var restart []string
+	for _, key := range changed {
+		if !liveKeys[key] {
+			restart = append(restart, key)
+		}
+	}
+	if len(restart) > 0 {
+		slog.Error("configuration reload rejected, these keys need a restart", slog.Any("keys", restart))
+		return
+	}
+
+	current.Store(snap)
+	slog.Info("configuration reloaded", slog.Any("keys", changed))
+
+	subscribersMu.Lock()
+	fns := append([]func(Config){}, subscribers...)
+	subscribersMu.Unlock()
+	for _, fn := range fns {
+		fn(*snap.conf)
+	}
+}
+
+// changedKeys keys whose raw value differs between two snapshots, sorted
+func changedKeys(old, new *snapshot) []string {
+	values := make(map[string]string, len(old.keys))
+	for _, key := range old.keys {
+		values[key.name] = key.value
+	}
+
+	var changed []string
+	for _, key := range new.keys {
+		if value, ok := values[key.name]; !ok || value != key.value {
+			changed = append(changed, key.name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
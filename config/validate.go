@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"rest-app/pkg/constants"
+	"rest-app/pkg/normalize"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Problem a missing or invalid configuration key
+type Problem struct {
+	Key    string
+	Reason string
+}
+
+func (p Problem) String() string {
+	return p.Key + ": " + p.Reason
+}
+
+// ValidationError lists every problem of a configuration, not only the first one
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p.String())
+	}
+	return fmt.Sprintf("invalid configuration, %d problem(s):\n%s", len(e.Problems), strings.Join(lines, "\n"))
+}
+
+var (
+	llmProviders     = []string{constants.LLM_PROVIDER_GOOGLEAI, constants.LLM_PROVIDER_HUGGINGFACE}
+	rateLimitBackend = []string{"memory"}
+	rateLimitKeyBy   = []string{"auto", "ip", "user", "api_key"}
+	imageFormats     = []string{"jpeg", "png", "webp", "tiff", "heic"}
+	logLevels        = []string{"debug", "info", "warn", "error"}
+	logFormats       = []string{"json", "text"}
+	tracingExporters = []string{"none", "otlp", "stdout", "file"}
+	cassetteModes    = []string{"off", "record", "replay"}
+	// promptTypes document types and the other prompts versioned like them
+	promptTypes = []string{constants.DOCUMENT_TYPE_RECEIPT, constants.PROMPT_RECEIPT_REPAIR}
+)
+
+// promptVersion prompt template versions are v followed by a number
+var promptVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// validator collects problems, its helpers return false when they added one
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(key, reason string, args ...any) {
+	v.problems = append(v.problems, Problem{Key: key, Reason: fmt.Sprintf(reason, args...)})
+}
+
+func (v *validator) required(key, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(key, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) oneOf(key, value string, allowed []string) {
+	if !slices.Contains(allowed, value) {
+		v.add(key, "%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+}
+
+func (v *validator) min(key string, value, min float64) {
+	if value < min {
+		v.add(key, "must be at least %v", min)
+	}
+}
+
+// oddKernel OpenCV only accepts odd kernel sizes for blurs and thresholds
+func (v *validator) oddKernel(key string, value, min int) {
+	if value < min || value%2 == 0 {
+		v.add(key, "must be odd and at least %d", min)
+	}
+}
+
+// Validate checks required keys and value ranges, sections of disabled features are only
+// checked once their feature is turned on
+func (c Config) Validate() error {
+	v := &validator{}
+
+	if c.Http.Port == 0 {
+		v.add("APP_PORT", "is required")
+	} else if c.Http.Port < 0 || c.Http.Port > 65535 {
+		v.add("APP_PORT", "must be between 1 and 65535")
+	}
+	v.required("SIGNING_KEY", c.JWT.SigningKey)
+
+	// Google AI is the fallback provider of every tenant
+	v.required("GOOGLE_AI_API_URL", c.GoogleAIAPIConf.URL)
+	v.required("GOOGLE_AI_API_MODEL", c.GoogleAIAPIConf.Model)
+	v.required("GOOGLE_AI_API_TOKEN", c.GoogleAIAPIConf.APIToken)
+	v.oneOf("GOOGLE_AI_HTTP_LOG_LEVEL", strings.ToLower(c.GoogleAIAPIConf.HTTPLog.Level), logLevels)
+
+	// Hugging Face only when it is the default provider or partly configured
+	hf := c.HuggingFaceAPIConf
+	if c.Tenant.DefaultLLMProvider == constants.LLM_PROVIDER_HUGGINGFACE || hf.URL != "" || hf.Model != "" || hf.APIToken != "" {
+		v.required("HUGGINGFACE_API_URL", hf.URL)
+		v.required("HUGGINGFACE_API_MODEL", hf.Model)
+		v.required("HUGGINGFACE_API_TOKEN", hf.APIToken)
+	}
+	v.oneOf("HUGGINGFACE_HTTP_LOG_LEVEL", strings.ToLower(hf.HTTPLog.Level), logLevels)
+
+	if c.DB.DSN != "" {
+		v.min("DB_MAX_OPEN_CONN", float64(c.DB.MaxOpenConn), 0)
+		v.min("DB_MAX_IDLE_CONN", float64(c.DB.MaxIdleConn), 0)
+		v.min("DB_MAX_LIFETIME_CONN", float64(c.DB.MaxLifetimeConn), 0)
+		v.min("DB_MAX_IDLETIME_CONN", float64(c.DB.MaxIdletimeConn), 0)
+	}
+
+	v.oneOf("TENANT_DEFAULT_LLM_PROVIDER", c.Tenant.DefaultLLMProvider, llmProviders)
+	if len(c.Tenant.DefaultDocumentTypes) == 0 {
+		v.add("TENANT_DEFAULT_DOCUMENT_TYPES", "is required")
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.Backend == "cache" {
+			v.add("RATE_LIMIT_BACKEND", "cache is not supported, the app cache is process local so it can't share limits between replicas")
+		} else {
+			v.oneOf("RATE_LIMIT_BACKEND", c.RateLimit.Backend, rateLimitBackend)
+		}
+		v.oneOf("RATE_LIMIT_KEY_BY", c.RateLimit.KeyBy, rateLimitKeyBy)
+		if c.RateLimit.Rate <= 0 {
+			v.add("RATE_LIMIT_RATE", "must be greater than 0")
+		}
+		v.min("RATE_LIMIT_BURST", float64(c.RateLimit.Burst), 1)
+	}
+
+	if c.Anonymous.Enabled {
+		if c.Anonymous.Rate <= 0 {
+			v.add("OCR_ANONYMOUS_RATE_LIMIT_RATE", "must be greater than 0")
+		}
+		v.min("OCR_ANONYMOUS_RATE_LIMIT_BURST", float64(c.Anonymous.Burst), 1)
+		// a captcha is what keeps anonymous calls from being scripted in production
+		if c.App.Env == constants.PRODUCTION {
+			v.required("CAPTCHA_VERIFY_URL", c.Anonymous.CaptchaVerifyURL)
+		}
+		if c.Anonymous.CaptchaVerifyURL != "" {
+			v.required("CAPTCHA_SECRET", c.Anonymous.CaptchaSecret)
+		}
+	}
+
+	v.min("OCR_POOL_SIZE", float64(c.OCR.PoolSize), 1)
+	v.min("OCR_MAX_UPLOAD_BYTES", float64(c.OCR.MaxUploadBytes), 1)
+	if len(c.OCR.AllowedImageFormats) == 0 {
+		v.add("OCR_ALLOWED_IMAGE_FORMATS", "is required")
+	}
+	for _, format := range c.OCR.AllowedImageFormats {
+		v.oneOf("OCR_ALLOWED_IMAGE_FORMATS", format, imageFormats)
+	}
+	for format := range c.OCR.MaxImagePixelsByFormat {
+		v.oneOf("OCR_MAX_IMAGE_PIXELS_BY_FORMAT", format, imageFormats)
+	}
+	if _, err := time.LoadLocation(c.OCR.DefaultTimezone); err != nil || c.OCR.DefaultTimezone == "" {
+		v.add("OCR_DEFAULT_TIMEZONE", "%q is not an IANA timezone like Asia/Jakarta", c.OCR.DefaultTimezone)
+	}
+	if code, ok := normalize.Currency(c.OCR.DefaultCurrency); !ok || code != c.OCR.DefaultCurrency {
+		v.add("OCR_DEFAULT_CURRENCY", "%q is not a known ISO 4217 code like IDR", c.OCR.DefaultCurrency)
+	}
+	if c.OCR.LogoMinScore <= 0 || c.OCR.LogoMinScore > 1 {
+		v.add("OCR_LOGO_MIN_SCORE", "must be greater than 0 and at most 1")
+	}
+	preprocess := c.OCR.Preprocess
+	v.min("OCR_PREPROCESS_MAX_DIMENSION", float64(preprocess.MaxDimension), 1)
+	v.oddKernel("OCR_PREPROCESS_BLUR_KERNEL", preprocess.BlurKernel, 1)
+	v.oddKernel("OCR_PREPROCESS_THRESHOLD_BLOCK_SIZE", preprocess.ThresholdBlockSize, 3)
+	v.min("OCR_PREPROCESS_MORPH_KERNEL", float64(preprocess.MorphKernel), 1)
+	v.oddKernel("OCR_PREPROCESS_MEDIAN_KERNEL", preprocess.MedianKernel, 3)
+
+	for _, name := range slices.Sorted(maps.Keys(c.Prompt.Versions)) {
+		versions := c.Prompt.Versions[name]
+		documentType, provider, hasProvider := strings.Cut(name, ".")
+		v.oneOf("PROMPT_VERSIONS", documentType, promptTypes)
+		if hasProvider {
+			v.oneOf("PROMPT_VERSIONS", provider, llmProviders)
+		}
+		for _, version := range versions {
+			if !promptVersion.MatchString(version) {
+				v.add("PROMPT_VERSIONS", "%s: %q is not a version like v1", name, version)
+			}
+		}
+	}
+
+	v.min("FEW_SHOT_MAX_EXAMPLES", float64(c.FewShot.MaxExamples), 0)
+	if c.FewShot.MinSimilarity < 0 || c.FewShot.MinSimilarity > 1 {
+		v.add("FEW_SHOT_MIN_SIMILARITY", "must be between 0 and 1")
+	}
+	if c.FewShot.RefreshInterval <= 0 {
+		v.add("FEW_SHOT_REFRESH_INTERVAL", "must be greater than 0")
+	}
+
+	if c.Health.Timeout <= 0 {
+		v.add("HEALTH_CHECK_TIMEOUT", "must be greater than 0")
+	}
+
+	if c.Shutdown.Timeout <= 0 {
+		v.add("SHUTDOWN_TIMEOUT", "must be greater than 0")
+	}
+
+	v.oneOf("TRACING_EXPORTER", c.Tracing.Exporter, tracingExporters)
+	if c.Tracing.Exporter == "file" {
+		v.required("TRACING_FILE_PATH", c.Tracing.FilePath)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.add("TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+	}
+
+	v.oneOf("LOG_LEVEL", strings.ToLower(c.Log.Level), logLevels)
+	v.oneOf("LOG_FORMAT", c.Log.Format, logFormats)
+
+	v.min("HTTP_LOG_MAX_BODY_BYTES", float64(c.HTTPLog.MaxBodyBytes), 0)
+	v.min("HTTP_CLIENT_RETRY_MAX_ATTEMPTS", float64(c.HTTPClient.RetryMaxAttempts), 1)
+	v.min("HTTP_CLIENT_BREAKER_FAILURE_THRESHOLD", float64(c.HTTPClient.BreakerFailureThreshold), 0)
+	// empty means off
+	if c.HTTPClient.CassetteMode != "" {
+		v.oneOf("HTTP_CASSETTE_MODE", c.HTTPClient.CassetteMode, cassetteModes)
+	}
+	if c.HTTPClient.CassetteMode == "record" || c.HTTPClient.CassetteMode == "replay" {
+		v.required("HTTP_CASSETTE_DIR", c.HTTPClient.CassetteDir)
+	}
+
+	v.min("CORS_MAX_AGE", c.CORS.MaxAge.Seconds(), 0)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
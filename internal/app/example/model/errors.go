@@ -0,0 +1,12 @@
+package model
+
+import "rest-app/pkg/apperror"
+
+var (
+	ErrNoDatabase       = apperror.New(apperror.Internal, "the example library needs a database")
+	ErrOCRTextRequired  = apperror.New(apperror.Validation, "example OCR text is required")
+	ErrInvalidData      = apperror.New(apperror.Validation, "example data must be a receipt JSON object")
+	ErrExampleNotFound  = apperror.New(apperror.NotFound, "example not found")
+	ErrReceiptNotFound  = apperror.New(apperror.NotFound, "receipt not found")
+	ErrReceiptHasNoText = apperror.New(apperror.Validation, "receipt has no OCR text to learn from")
+)
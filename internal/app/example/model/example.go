@@ -0,0 +1,22 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Example an OCR text with the receipt JSON expected from it, shown to the LLM as a few-shot example.
+// TenantID nil shares it with every tenant, otherwise only that tenant's extractions use it
+type Example struct {
+	ID        string          `json:"id" gorm:"column:id;primaryKey;default:uuid_generate_v4()"`
+	TenantID  *string         `json:"tenant_id" gorm:"column:tenant_id"`
+	BankName  string          `json:"bank_name" gorm:"column:bank_name"`
+	OCRText   string          `json:"ocr_text" gorm:"column:ocr_text"`
+	Data      json.RawMessage `json:"data" gorm:"column:data;type:jsonb"`
+	ReceiptID *string         `json:"receipt_id" gorm:"column:receipt_id"` // the receipt it was promoted from
+	CreatedAt time.Time       `json:"created_at" gorm:"column:created_at"`
+}
+
+func (Example) TableName() string {
+	return "extraction_examples"
+}
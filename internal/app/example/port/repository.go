@@ -0,0 +1,15 @@
+package port
+
+import (
+	"context"
+	"rest-app/internal/app/example/model"
+)
+
+type IExampleRepository interface {
+	List(ctx context.Context) ([]model.Example, error)
+	// ListShared the examples without tenant, shared with every tenant
+	ListShared(ctx context.Context) ([]model.Example, error)
+	Create(ctx context.Context, example *model.Example) error
+	// Delete reports whether an example was deleted
+	Delete(ctx context.Context, id string) (bool, error)
+}
@@ -0,0 +1,20 @@
+package port
+
+import (
+	"context"
+	"encoding/json"
+	"rest-app/config"
+	"rest-app/internal/app/example/model"
+)
+
+type IExampleService interface {
+	// Select returns the examples of the library closest to the OCR text, available to the tenant of ctx
+	Select(ctx context.Context, text string) ([]model.Example, error)
+	List(ctx context.Context) ([]model.Example, error)
+	Add(ctx context.Context, example *model.Example) error
+	// Promote adds the OCR text of a stored receipt with the data a reviewer corrected as an example,
+	// owned by the receipt's tenant unless shared
+	Promote(ctx context.Context, receiptID string, corrected json.RawMessage, shared bool) (*model.Example, error)
+	Delete(ctx context.Context, id string) error
+	SetConfig(conf config.FewShotConf)
+}
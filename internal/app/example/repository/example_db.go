@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"rest-app/config/db"
+	"rest-app/internal/app/example/model"
+	"rest-app/internal/app/example/port"
+	"rest-app/pkg/tenant"
+	"rest-app/pkg/transaction"
+)
+
+type exampleDB struct {
+	db *db.GormDB
+}
+
+func NewExampleDB(db *db.GormDB) port.IExampleRepository {
+	return &exampleDB{
+		db: db,
+	}
+}
+
+func (r *exampleDB) List(ctx context.Context) ([]model.Example, error) {
+	var examples []model.Example
+
+	err := transaction.GetTrxContext(ctx, r.db).
+		Order("created_at").
+		Find(&examples).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return examples, nil
+}
+
+func (r *exampleDB) ListShared(ctx context.Context) ([]model.Example, error) {
+	var examples []model.Example
+
+	// the tenant scope would exclude the rows without tenant, only those are read
+	err := transaction.GetTrxContext(tenant.WithTenantID(ctx, ""), r.db).
+		Where("tenant_id IS NULL").
+		Order("created_at").
+		Find(&examples).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return examples, nil
+}
+
+func (r *exampleDB) Create(ctx context.Context, example *model.Example) error {
+	return transaction.GetTrxContext(ctx, r.db).Create(example).Error
+}
+
+func (r *exampleDB) Delete(ctx context.Context, id string) (bool, error) {
+	res := transaction.GetTrxContext(ctx, r.db).Where("id = ?", id).Delete(&model.Example{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"rest-app/config"
+	"rest-app/internal/app/example/model"
+	"rest-app/internal/app/example/port"
+	"rest-app/pkg/tenant"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	ocrModel "rest-app/internal/app/ocr/model"
+	ocrPort "rest-app/internal/app/ocr/port"
+
+	"gorm.io/gorm"
+)
+
+// genericBankWords words of bank names that don't tell banks apart
+var genericBankWords = map[string]bool{"bank": true, "pt": true, "tbk": true, "persero": true}
+
+// indexed an example with the words its similarity is computed on
+type indexed struct {
+	example   model.Example
+	words     map[string]bool
+	bankWords []string
+}
+
+type exampleService struct {
+	conf        atomic.Pointer[config.FewShotConf]
+	exampleRepo port.IExampleRepository
+	receiptRepo ocrPort.IReceiptRepository
+
+	mu        sync.Mutex
+	libraries map[string]*library // by tenant id, the shared examples under ""
+}
+
+// library the examples of a tenant, or the shared ones
+type library struct {
+	examples []indexed
+	loadedAt time.Time
+}
+
+// NewExampleService exampleRepo and receiptRepo are nil without a database, Select then finds nothing
+func NewExampleService(conf *config.FewShotConf, exampleRepo port.IExampleRepository, receiptRepo ocrPort.IReceiptRepository) port.IExampleService {
+	s := &exampleService{
+		exampleRepo: exampleRepo,
+		receiptRepo: receiptRepo,
+		libraries:   map[string]*library{},
+	}
+	s.conf.Store(conf)
+	return s
+}
+
+// SetConfig swaps the selection settings used by the next extractions
+func (s *exampleService) SetConfig(conf config.FewShotConf) {
+	s.conf.Store(&conf)
+}
+
+// Select ranks the examples by the share of words they have in common with text, an example whose
+// bank is named in text ranks first and is picked whatever its similarity
+func (s *exampleService) Select(ctx context.Context, text string) ([]model.Example, error) {
+	conf := s.conf.Load()
+	if conf.MaxExamples == 0 || s.exampleRepo == nil {
+		return nil, nil
+	}
+
+	entries, err := s.cached(ctx, "", conf.RefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+	if tenantID, ok := tenant.FromContext(ctx); ok {
+		owned, err := s.cached(ctx, tenantID, conf.RefreshInterval)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(owned[:len(owned):len(owned)], entries...)
+	}
+
+	textWords := words(text)
+
+	type candidate struct {
+		example model.Example
+		score   float64
+	}
+	var candidates []candidate
+	for _, entry := range entries {
+		score := similarity(textWords, entry.words)
+		if len(entry.bankWords) > 0 && containsAll(textWords, entry.bankWords) {
+			score++
+		} else if score < conf.MinSimilarity {
+			continue
+		}
+		candidates = append(candidates, candidate{example: entry.example, score: score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	examples := make([]model.Example, 0, min(len(candidates), conf.MaxExamples))
+	for _, c := range candidates[:min(len(candidates), conf.MaxExamples)] {
+		examples = append(examples, c.example)
+	}
+	return examples, nil
+}
+
+// cached returns the library of tenantID, the shared one for "", read again once it is older
+// than refresh. A failed read keeps serving the previous one
+func (s *exampleService) cached(ctx context.Context, tenantID string, refresh time.Duration) ([]indexed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lib := s.libraries[tenantID]
+	if lib != nil && time.Since(lib.loadedAt) < refresh {
+		return lib.examples, nil
+	}
+
+	// a tenant's examples are read through the tenant scope of ctx
+	var examples []model.Example
+	var err error
+	if tenantID == "" {
+		examples, err = s.exampleRepo.ListShared(ctx)
+	} else {
+		examples, err = s.exampleRepo.List(ctx)
+	}
+	if err != nil {
+		if lib == nil {
+			return nil, fmt.Errorf("failed to list examples: %w", err)
+		}
+		lib.loadedAt = time.Now()
+		return lib.examples, fmt.Errorf("failed to refresh examples: %w", err)
+	}
+
+	indexedExamples := make([]indexed, 0, len(examples))
+	for _, example := range examples {
+		var bankWords []string
+		for word := range words(example.BankName) {
+			if !genericBankWords[word] {
+				bankWords = append(bankWords, word)
+			}
+		}
+		indexedExamples = append(indexedExamples, indexed{example: example, words: words(example.OCRText), bankWords: bankWords})
+	}
+	s.libraries[tenantID] = &library{examples: indexedExamples, loadedAt: time.Now()}
+	return indexedExamples, nil
+}
+
+func (s *exampleService) List(ctx context.Context) ([]model.Example, error) {
+	if s.exampleRepo == nil {
+		return nil, model.ErrNoDatabase
+	}
+	return s.exampleRepo.List(ctx)
+}
+
+func (s *exampleService) Add(ctx context.Context, example *model.Example) error {
+	if s.exampleRepo == nil {
+		return model.ErrNoDatabase
+	}
+	if strings.TrimSpace(example.OCRText) == "" {
+		return model.ErrOCRTextRequired
+	}
+
+	receipt, err := parseReceipt(example.Data)
+	if err != nil {
+		return err
+	}
+	if example.BankName == "" {
+		example.BankName = receipt.BankName
+	}
+
+	if err := s.exampleRepo.Create(ctx, example); err != nil {
+		return fmt.Errorf("failed to store example: %w", err)
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *exampleService) Promote(ctx context.Context, receiptID string, corrected json.RawMessage, shared bool) (*model.Example, error) {
+	if s.exampleRepo == nil || s.receiptRepo == nil {
+		return nil, model.ErrNoDatabase
+	}
+
+	receipt, err := s.receiptRepo.GetByID(ctx, receiptID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, model.ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	if strings.TrimSpace(receipt.OCRText) == "" {
+		return nil, model.ErrReceiptHasNoText
+	}
+
+	example := &model.Example{
+		OCRText:   receipt.OCRText,
+		Data:      corrected,
+		ReceiptID: &receipt.ID,
+	}
+	if !shared && receipt.TenantID != "" {
+		example.TenantID = &receipt.TenantID
+	}
+
+	if err := s.Add(ctx, example); err != nil {
+		return nil, err
+	}
+	return example, nil
+}
+
+func (s *exampleService) Delete(ctx context.Context, id string) error {
+	if s.exampleRepo == nil {
+		return model.ErrNoDatabase
+	}
+
+	deleted, err := s.exampleRepo.Delete(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete example: %w", err)
+	}
+	if !deleted {
+		return model.ErrExampleNotFound
+	}
+	s.invalidate()
+	return nil
+}
+
+// invalidate makes the next Select read the libraries again
+func (s *exampleService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.libraries = map[string]*library{}
+}
+
+// parseReceipt rejects data that isn't a receipt, a misspelled field would teach the LLM a wrong one
+func parseReceipt(data json.RawMessage) (*ocrModel.ReceiptTransaction, error) {
+	var receipt ocrModel.ReceiptTransaction
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&receipt); err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidData, err)
+	}
+	return &receipt, nil
+}
+
+// words the lower cased words of text, those with a digit are left out as amounts, dates and
+// account numbers differ between receipts of the same layout
+func words(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) < 2 || strings.ContainsFunc(word, unicode.IsDigit) {
+			continue
+		}
+		set[word] = true
+	}
+	return set
+}
+
+// similarity Jaccard index of two word sets
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+func containsAll(set map[string]bool, words []string) bool {
+	for _, word := range words {
+		if !set[word] {
+			return false
+		}
+	}
+	return true
+}
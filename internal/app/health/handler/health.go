@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"rest-app/internal/app/health/model"
+	"rest-app/internal/app/health/port"
+	"rest-app/pkg/helper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type handler struct {
+	healthService port.IHealthService
+}
+
+func New(healthService port.IHealthService) port.IHealthCheckHandler {
+	return &handler{
+		healthService: healthService,
+	}
+}
+
+// Liveness only tells the process is able to serve http, it never checks dependencies
+func (h *handler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, &helper.Response{
+		Success: true,
+		Message: "OK",
+		Data: model.Report{
+			Status: model.StatusUp,
+		},
+	})
+}
+
+// Readiness reports every dependency, it fails with 503 when a critical one is down
+func (h *handler) Readiness(c *gin.Context) {
+	report := h.healthService.Readiness(c.Request.Context())
+
+	code := http.StatusOK
+	if report.Status == model.StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, &helper.Response{
+		Success: code == http.StatusOK,
+		Message: http.StatusText(code),
+		Data:    report,
+	})
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Checker a single readiness dependency check
+type Checker struct {
+	Name string
+	// Critical checkers take the whole app down when failing, others only degrade it
+	Critical bool
+	// CacheFor reuses the last result for that long, for checks that cost money or quota
+	CacheFor time.Duration
+	Check    func(ctx context.Context) error
+}
+
+type ComponentStatus struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type Report struct {
+	Status     string            `json:"status"`
+	Components []ComponentStatus `json:"components,omitempty"`
+}
@@ -0,0 +1,8 @@
+package port
+
+import "github.com/gin-gonic/gin"
+
+type IHealthCheckHandler interface {
+	Liveness(ctx *gin.Context)
+	Readiness(ctx *gin.Context)
+}
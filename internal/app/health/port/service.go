@@ -0,0 +1,10 @@
+package port
+
+import (
+	"context"
+	"rest-app/internal/app/health/model"
+)
+
+type IHealthService interface {
+	Readiness(ctx context.Context) model.Report
+}
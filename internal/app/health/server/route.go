@@ -0,0 +1,20 @@
+package health
+
+import (
+	"rest-app/internal/app/health/port"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	routes struct{}
+)
+
+var (
+	Routes routes
+)
+
+func (r routes) New(router *gin.RouterGroup, handler port.IHealthCheckHandler) {
+	router.GET("/healthz", handler.Liveness)
+	router.GET("/readyz", handler.Readiness)
+}
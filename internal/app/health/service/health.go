@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"rest-app/internal/app/health/model"
+	"rest-app/internal/app/health/port"
+	"sync"
+	"time"
+)
+
+type health struct {
+	checkers []model.Checker
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	cached map[string]model.ComponentStatus
+}
+
+// NewHealthService every check is given at most timeout to complete
+func NewHealthService(timeout time.Duration, checkers ...model.Checker) port.IHealthService {
+	return &health{
+		checkers: checkers,
+		timeout:  timeout,
+		cached:   map[string]model.ComponentStatus{},
+	}
+}
+
+func (h *health) Readiness(ctx context.Context) model.Report {
+	report := model.Report{
+		Status:     model.StatusUp,
+		Components: make([]model.ComponentStatus, len(h.checkers)),
+	}
+
+	var wg sync.WaitGroup
+	for i, checker := range h.checkers {
+		wg.Add(1)
+		go func(i int, checker model.Checker) {
+			defer wg.Done()
+			report.Components[i] = h.check(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	for _, component := range report.Components {
+		if component.Status == model.StatusUp {
+			continue
+		}
+		if component.Critical {
+			report.Status = model.StatusDown
+			break
+		}
+		report.Status = model.StatusDegraded
+	}
+
+	return report
+}
+
+func (h *health) check(ctx context.Context, checker model.Checker) model.ComponentStatus {
+	if checker.CacheFor > 0 {
+		h.mu.Lock()
+		status, ok := h.cached[checker.Name]
+		h.mu.Unlock()
+		if ok && time.Since(status.CheckedAt) < checker.CacheFor {
+			return status
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runWithContext(ctx, checker.Check)
+
+	status := model.ComponentStatus{
+		Name:      checker.Name,
+		Status:    model.StatusUp,
+		Critical:  checker.Critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		status.Status = model.StatusDown
+		status.Error = err.Error()
+	}
+
+	if checker.CacheFor > 0 {
+		h.mu.Lock()
+		h.cached[checker.Name] = status
+		h.mu.Unlock()
+	}
+
+	return status
+}
+
+// runWithContext returns as soon as ctx is done, even when check ignores it
+func runWithContext(ctx context.Context, check func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,76 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"rest-app/internal/app/ocr/model"
+)
+
+// imageExtensions files of a corpus read as images
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".webp", ".tif", ".tiff", ".heic"}
+
+// Sample a labelled image, ExpectedText is only set when the corpus has a transcript for it
+type Sample struct {
+	Name         string
+	ImagePath    string
+	Expected     model.ReceiptTransaction
+	ExpectedText *string
+}
+
+// LoadCorpus reads a directory where every image <name>.<ext> comes with <name>.json, the expected
+// receipt, and optionally <name>.txt, the exact text of the image used for the character error rate
+func LoadCorpus(dir string) ([]Sample, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []Sample
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !isImage(ext) {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		sample := Sample{Name: name, ImagePath: filepath.Join(dir, entry.Name())}
+
+		expected, err := os.ReadFile(filepath.Join(dir, name+".json"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: no expected receipt: %w", entry.Name(), err)
+		}
+		if err := json.Unmarshal(expected, &sample.Expected); err != nil {
+			return nil, fmt.Errorf("%s.json: %w", name, err)
+		}
+
+		text, err := os.ReadFile(filepath.Join(dir, name+".txt"))
+		if err == nil {
+			expectedText := string(text)
+			sample.ExpectedText = &expectedText
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		samples = append(samples, sample)
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("%s: no image found", dir)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Name < samples[j].Name })
+	return samples, nil
+}
+
+func isImage(ext string) bool {
+	for _, imageExt := range imageExtensions {
+		if ext == imageExt {
+			return true
+		}
+	}
+	return false
+}
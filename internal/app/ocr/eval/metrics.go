@@ -0,0 +1,146 @@
+package eval
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"rest-app/internal/app/ocr/model"
+)
+
+// amountTolerance amounts this close are a normalized match, LLMs round some fractions
+const amountTolerance = 0.005
+
+// fieldValue a receipt field named by its JSON key
+type fieldValue struct {
+	name  string
+	value interface{}
+}
+
+// receiptFields lists the fields of r in declaration order, so new fields are scored without changes here
+func receiptFields(r model.ReceiptTransaction) []fieldValue {
+	v := reflect.ValueOf(r)
+	t := v.Type()
+
+	fields := make([]fieldValue, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, fieldValue{name: name, value: v.Field(i).Interface()})
+	}
+	return fields
+}
+
+// compareField tells whether got equals expected exactly and once normalized
+func compareField(expected, got interface{}) (exact, normalized bool) {
+	switch expected := expected.(type) {
+	case string:
+		got, _ := got.(string)
+		return expected == got, normalizeText(expected) == normalizeText(got)
+	case float64:
+		got, _ := got.(float64)
+		return expected == got, math.Abs(expected-got) < amountTolerance
+	default:
+		equal := reflect.DeepEqual(expected, got)
+		return equal, equal
+	}
+}
+
+// normalizeText keeps lower cased letters and digits, so "BCA " matches "bca" and "123-456" matches "123 456"
+func normalizeText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// formatValue a field value as shown in reports
+func formatValue(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// collapseSpaces turns every run of white space into one space, OCR layout is not scored
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// editDistance Levenshtein distance between a and b counted in runes
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// Latency percentiles in milliseconds
+type Latency struct {
+	Mean float64 `json:"mean_ms"`
+	P50  float64 `json:"p50_ms"`
+	P90  float64 `json:"p90_ms"`
+	P95  float64 `json:"p95_ms"`
+	P99  float64 `json:"p99_ms"`
+	Max  float64 `json:"max_ms"`
+}
+
+func latencyOf(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	// nearest rank, small corpora report an observed latency rather than an interpolated one
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		return milliseconds(sorted[max(rank, 0)])
+	}
+
+	return Latency{
+		Mean: milliseconds(total / time.Duration(len(sorted))),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P95:  percentile(95),
+		P99:  percentile(99),
+		Max:  milliseconds(sorted[len(sorted)-1]),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*10) / 10
+}
@@ -0,0 +1,351 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"rest-app/internal/app/ocr/model"
+)
+
+// Extractor runs one image through the OCR pipeline, IOCRService.Extract
+type Extractor func(ctx context.Context, imgBytes []byte) (*model.Extraction, error)
+
+// Report result of a run, written as JSON it is the baseline of the next one.
+// Accuracies are over every sample, a failed extraction misses all its fields
+type Report struct {
+	Corpus    string         `json:"corpus"`
+	StartedAt time.Time      `json:"started_at"`
+	Samples   int            `json:"samples"`
+	Failed    int            `json:"failed"`
+	Fields    []FieldScore   `json:"fields"`
+	Overall   FieldScore     `json:"overall"`
+	CER       *float64       `json:"cer,omitempty"`
+	Latency   Latency        `json:"latency"`
+	Results   []SampleResult `json:"results"`
+}
+
+// FieldScore share of samples where the field matched, from 0 to 1
+type FieldScore struct {
+	Field      string  `json:"field"`
+	Exact      float64 `json:"exact"`
+	Normalized float64 `json:"normalized"`
+}
+
+// SampleResult CER is only set for samples with a transcript
+type SampleResult struct {
+	Name          string        `json:"name"`
+	PromptVersion string        `json:"prompt_version,omitempty"`
+	LatencyMS     float64       `json:"latency_ms"`
+	Error         string        `json:"error,omitempty"`
+	CER           *float64      `json:"cer,omitempty"`
+	Fields        []FieldResult `json:"fields"`
+}
+
+type FieldResult struct {
+	Field      string `json:"field"`
+	Expected   string `json:"expected"`
+	Got        string `json:"got"`
+	Exact      bool   `json:"exact"`
+	Normalized bool   `json:"normalized"`
+}
+
+// Run extracts every sample, concurrency of them at once. Latencies include the wait for a free
+// tesseract client, keep concurrency at most OCR_POOL_SIZE to measure the pipeline alone
+func Run(ctx context.Context, extract Extractor, corpus string, samples []Sample, concurrency int) *Report {
+	report := &Report{Corpus: corpus, StartedAt: time.Now().UTC(), Samples: len(samples)}
+	report.Results = make([]SampleResult, len(samples))
+	durations := make([]time.Duration, len(samples))
+	texts := make([]string, len(samples))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < max(concurrency, 1); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report.Results[i], durations[i], texts[i] = runSample(ctx, extract, samples[i])
+			}
+		}()
+	}
+	for i := range samples {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	report.Latency = latencyOf(durations)
+	report.score(samples, texts)
+	return report
+}
+
+func runSample(ctx context.Context, extract Extractor, sample Sample) (SampleResult, time.Duration, string) {
+	result := SampleResult{Name: sample.Name}
+
+	imgBytes, err := os.ReadFile(sample.ImagePath)
+	if err != nil {
+		result.Error = err.Error()
+		result.Fields = scoreFields(sample.Expected, nil)
+		return result, 0, ""
+	}
+
+	start := time.Now()
+	extraction, err := extract(ctx, imgBytes)
+	duration := time.Since(start)
+	result.LatencyMS = milliseconds(duration)
+
+	if err != nil {
+		result.Error = err.Error()
+		result.Fields = scoreFields(sample.Expected, nil)
+		return result, duration, ""
+	}
+	result.PromptVersion = extraction.PromptVersion
+	result.Fields = scoreFields(sample.Expected, extraction.Receipt)
+	return result, duration, extraction.Text
+}
+
+// scoreFields compares every field of got with expected, a nil got misses them all
+func scoreFields(expected model.ReceiptTransaction, got *model.ReceiptTransaction) []FieldResult {
+	expectedFields := receiptFields(expected)
+	results := make([]FieldResult, len(expectedFields))
+
+	var gotFields []fieldValue
+	if got != nil {
+		gotFields = receiptFields(*got)
+	}
+
+	for i, field := range expectedFields {
+		results[i] = FieldResult{Field: field.name, Expected: formatValue(field.value)}
+		if gotFields == nil {
+			continue
+		}
+		results[i].Got = formatValue(gotFields[i].value)
+		results[i].Exact, results[i].Normalized = compareField(field.value, gotFields[i].value)
+	}
+	return results
+}
+
+// score fills the per field accuracies and the character error rate of the corpus, which is
+// the edits over all transcripts divided by their length so long receipts weigh more
+func (r *Report) score(samples []Sample, texts []string) {
+	var (
+		exact, normalized []int
+		edits, length     int
+	)
+
+	for i, result := range r.Results {
+		if result.Error != "" {
+			r.Failed++
+		}
+		if exact == nil {
+			exact = make([]int, len(result.Fields))
+			normalized = make([]int, len(result.Fields))
+			for _, field := range result.Fields {
+				r.Fields = append(r.Fields, FieldScore{Field: field.Field})
+			}
+		}
+		for j, field := range result.Fields {
+			if field.Exact {
+				exact[j]++
+			}
+			if field.Normalized {
+				normalized[j]++
+			}
+		}
+
+		if samples[i].ExpectedText == nil || result.Error != "" {
+			continue
+		}
+		expected := []rune(collapseSpaces(*samples[i].ExpectedText))
+		sampleEdits := editDistance(expected, []rune(collapseSpaces(texts[i])))
+		edits += sampleEdits
+		length += len(expected)
+		if len(expected) > 0 {
+			cer := float64(sampleEdits) / float64(len(expected))
+			r.Results[i].CER = &cer
+		}
+	}
+
+	var totalExact, totalNormalized int
+	for j := range r.Fields {
+		r.Fields[j].Exact = float64(exact[j]) / float64(r.Samples)
+		r.Fields[j].Normalized = float64(normalized[j]) / float64(r.Samples)
+		totalExact += exact[j]
+		totalNormalized += normalized[j]
+	}
+	if len(r.Fields) > 0 {
+		cells := float64(r.Samples * len(r.Fields))
+		r.Overall = FieldScore{Field: "overall", Exact: float64(totalExact) / cells, Normalized: float64(totalNormalized) / cells}
+	}
+
+	if length > 0 {
+		cer := float64(edits) / float64(length)
+		r.CER = &cer
+	}
+}
+
+// Regression a field matching in the baseline but not anymore, Field is empty when the whole
+// extraction started failing
+type Regression struct {
+	Sample   string `json:"sample"`
+	Field    string `json:"field,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Got      string `json:"got"`
+}
+
+// Compare lists what got worse since baseline, samples and fields missing from either run are ignored
+func Compare(baseline, current *Report) []Regression {
+	before := make(map[string]SampleResult, len(baseline.Results))
+	for _, result := range baseline.Results {
+		before[result.Name] = result
+	}
+
+	var regressions []Regression
+	for _, result := range current.Results {
+		previous, ok := before[result.Name]
+		if !ok {
+			continue
+		}
+		if result.Error != "" {
+			if previous.Error == "" {
+				regressions = append(regressions, Regression{Sample: result.Name, Got: result.Error})
+			}
+			continue
+		}
+
+		matched := make(map[string]bool, len(previous.Fields))
+		for _, field := range previous.Fields {
+			matched[field.Field] = field.Normalized
+		}
+		for _, field := range result.Fields {
+			if matched[field.Field] && !field.Normalized {
+				regressions = append(regressions, Regression{Sample: result.Name, Field: field.Field, Expected: field.Expected, Got: field.Got})
+			}
+		}
+	}
+	return regressions
+}
+
+// Print writes report as tables, with a baseline every score gets its change next to it
+func Print(w io.Writer, report, baseline *Report) error {
+	fmt.Fprintf(w, "corpus %s: %d samples, %d failed\n\n", report.Corpus, report.Samples, report.Failed)
+
+	baselineFields := map[string]FieldScore{}
+	if baseline != nil {
+		for _, field := range baseline.Fields {
+			baselineFields[field.Field] = field
+		}
+		baselineFields[baseline.Overall.Field] = baseline.Overall
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if baseline != nil {
+		fmt.Fprintln(tw, "field\texact\tnormalized\tvs baseline\t")
+	} else {
+		fmt.Fprintln(tw, "field\texact\tnormalized\t")
+	}
+	for _, field := range slices.Concat(report.Fields, []FieldScore{report.Overall}) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t", field.Field, percent(field.Exact), percent(field.Normalized))
+		if baseline != nil {
+			if previous, ok := baselineFields[field.Field]; ok {
+				fmt.Fprintf(tw, "%s\t", delta(field.Normalized-previous.Normalized))
+			} else {
+				fmt.Fprint(tw, "new\t")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+
+	switch {
+	case report.CER == nil:
+		fmt.Fprintln(w, "character error rate: no transcript in the corpus")
+	case baseline != nil && baseline.CER != nil:
+		fmt.Fprintf(w, "character error rate: %s (baseline %s, %s)\n", percent(*report.CER), percent(*baseline.CER), delta(*report.CER-*baseline.CER))
+	default:
+		fmt.Fprintf(w, "character error rate: %s\n", percent(*report.CER))
+	}
+
+	l := report.Latency
+	fmt.Fprintf(w, "latency ms: mean %.1f  p50 %.1f  p90 %.1f  p95 %.1f  p99 %.1f  max %.1f\n", l.Mean, l.P50, l.P90, l.P95, l.P99, l.Max)
+	if baseline != nil {
+		b := baseline.Latency
+		fmt.Fprintf(w, "  baseline: mean %.1f  p50 %.1f  p90 %.1f  p95 %.1f  p99 %.1f  max %.1f\n", b.Mean, b.P50, b.P90, b.P95, b.P99, b.Max)
+	}
+
+	printPromptVersions(w, report)
+
+	for _, result := range report.Results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "failed %s: %s\n", result.Name, result.Error)
+		}
+	}
+
+	if baseline == nil {
+		return nil
+	}
+	regressions := Compare(baseline, report)
+	fmt.Fprintf(w, "\n%d regressions since %s\n", len(regressions), baseline.StartedAt.Format(time.RFC3339))
+	for _, regression := range regressions {
+		if regression.Field == "" {
+			fmt.Fprintf(w, "  %s: now fails: %s\n", regression.Sample, regression.Got)
+			continue
+		}
+		fmt.Fprintf(w, "  %s %s: expected %q, got %q\n", regression.Sample, regression.Field, regression.Expected, regression.Got)
+	}
+	return nil
+}
+
+// printPromptVersions normalized accuracy per prompt version when a PROMPT_VERSIONS split used several,
+// failed extractions have no version and are left out
+func printPromptVersions(w io.Writer, report *Report) {
+	type versionScore struct{ samples, matched, fields int }
+	scores := map[string]*versionScore{}
+	var versions []string
+
+	for _, result := range report.Results {
+		if result.PromptVersion == "" {
+			continue
+		}
+		score, ok := scores[result.PromptVersion]
+		if !ok {
+			score = &versionScore{}
+			scores[result.PromptVersion] = score
+			versions = append(versions, result.PromptVersion)
+		}
+		score.samples++
+		for _, field := range result.Fields {
+			score.fields++
+			if field.Normalized {
+				score.matched++
+			}
+		}
+	}
+	if len(versions) < 2 {
+		return
+	}
+
+	slices.Sort(versions)
+	for _, version := range versions {
+		score := scores[version]
+		fmt.Fprintf(w, "prompt %s: %d samples, %s normalized\n", version, score.samples, percent(float64(score.matched)/float64(score.fields)))
+	}
+}
+
+func percent(ratio float64) string {
+	return fmt.Sprintf("%.1f%%", ratio*100)
+}
+
+// delta change of a ratio in percentage points
+func delta(change float64) string {
+	return fmt.Sprintf("%+.1f", change*100)
+}
@@ -1,91 +1,85 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
+	"rest-app/internal/app/ocr/model"
 	"rest-app/internal/app/ocr/port"
+	"rest-app/pkg/apperror"
 	"rest-app/pkg/helper"
-	"strings"
+	"rest-app/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 )
 
+// multipartOverhead room for the multipart boundaries and headers on top of the file itself
+const multipartOverhead = 64 << 10
+
 type handler struct {
-	ocrService port.IOCRService
+	ocrService     port.IOCRService
+	maxUploadBytes int64
 }
 
-func New(ocrService port.IOCRService) port.IOCRHandler {
+func New(ocrService port.IOCRService, maxUploadBytes int64) port.IOCRHandler {
 	return &handler{
-		ocrService: ocrService,
+		ocrService:     ocrService,
+		maxUploadBytes: maxUploadBytes,
 	}
 }
 
 func (h *handler) ProcessReceipt(c *gin.Context) {
-	const maxFileSize = 5 << 20 // 5 MB
+	ctx, span := tracing.Start(c.Request.Context(), "ocr.ProcessReceipt")
+	defer span.End()
+	c.Request = c.Request.WithContext(ctx)
+
+	// never read more than an upload can weigh, whatever Content-Length claims
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadBytes+multipartOverhead)
 
-	if err := c.Request.ParseMultipartForm(maxFileSize); err != nil {
-		if err == http.ErrNotMultipart {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Request must be multipart/form-data",
-			})
+	if err := c.Request.ParseMultipartForm(h.maxUploadBytes); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.Is(err, http.ErrNotMultipart) {
+			helper.ResponseError(c, model.ErrNotMultipart)
 			return
 		}
-		if strings.Contains(err.Error(), "request body too large") {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "File too large, max size is 5MB",
-			})
+		if errors.As(err, &maxBytesErr) {
+			helper.ResponseError(c, h.errFileTooLarge())
 			return
 		}
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		helper.ResponseError(c, apperror.Wrap(apperror.Validation, err, "invalid multipart form"))
 		return
 	}
 
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		helper.ResponseError(c, model.ErrFileRequired)
 		return
 	}
 
-	allowedExtensions := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".pdf":  true,
-	}
-	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	if !allowedExtensions[ext] {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("File extension %s not allowed", ext),
-		})
+	// the content type is sniffed from the bytes by the service, the filename is not trusted
+	if fileHeader.Size > h.maxUploadBytes {
+		helper.ResponseError(c, h.errFileTooLarge())
 		return
 	}
 
 	file, err := fileHeader.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to open uploaded file",
-		})
+		helper.ResponseError(c, apperror.Wrap(apperror.Internal, err, "Failed to open uploaded file"))
 		return
 	}
 	defer file.Close()
 
 	fileBytes, err := io.ReadAll(file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to read file content",
-		})
+		helper.ResponseError(c, apperror.Wrap(apperror.Internal, err, "Failed to read file content"))
 		return
 	}
 
 	// Process the file with your OCR service
 	res, err := h.ocrService.ReceiptDataGenerator(c, fileBytes)
 	if err != nil {
+		tracing.Fail(span, err)
 		helper.ResponseError(c, err)
 		return
 	}
@@ -96,3 +90,7 @@ func (h *handler) ProcessReceipt(c *gin.Context) {
 		Data:    res, // Include the result if available
 	})
 }
+
+func (h *handler) errFileTooLarge() error {
+	return apperror.Wrap(apperror.PayloadTooLarge, model.ErrFileTooLarge, fmt.Sprintf("File too large, max size is %.1fMB", float64(h.maxUploadBytes)/(1<<20)))
+}
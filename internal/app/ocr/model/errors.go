@@ -0,0 +1,12 @@
+package model
+
+import "rest-app/pkg/apperror"
+
+var (
+	ErrDocumentTypeNotAllowed = apperror.New(apperror.Forbidden, "document type is not allowed for this tenant")
+	// ErrOCRLowQuality too little text was recognized for the LLM to work with
+	ErrOCRLowQuality = apperror.New(apperror.OCRLowQuality, "no readable text found in the image, send a sharper or better lit picture")
+	ErrFileRequired  = apperror.New(apperror.Validation, "file is required")
+	ErrNotMultipart  = apperror.New(apperror.Validation, "request must be multipart/form-data")
+	ErrFileTooLarge  = apperror.New(apperror.PayloadTooLarge, "file too large")
+)
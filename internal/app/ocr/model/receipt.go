@@ -0,0 +1,38 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"rest-app/pkg/tenant"
+)
+
+// Receipt is a persisted extraction result
+type Receipt struct {
+	tenant.Owned
+	ID           string `json:"id" gorm:"column:id;primaryKey;default:uuid_generate_v4()"`
+	DocumentType string `json:"document_type" gorm:"column:document_type"`
+	LLMProvider  string `json:"llm_provider" gorm:"column:llm_provider"`
+	// PromptVersion version of the prompt template the result was extracted with
+	PromptVersion string `json:"prompt_version" gorm:"column:prompt_version"`
+	// RepairPromptVersion version of the repair prompt Data was corrected with, empty when it wasn't
+	RepairPromptVersion string          `json:"repair_prompt_version" gorm:"column:repair_prompt_version"`
+	OCRText             string          `json:"ocr_text" gorm:"column:ocr_text"`
+	Data                json.RawMessage `json:"data" gorm:"column:data;type:jsonb"`
+	// Warnings the validation rules Data still breaks, null when it breaks none
+	Warnings json.RawMessage `json:"warnings" gorm:"column:warnings;type:jsonb"`
+	// TransactionDate, TransactedAt, Currency, AmountMinor and FeeMinor the normalized values of Data,
+	// null when they don't parse. Amounts are in minor units of Currency, see NormalizedReceipt
+	TransactionDate *time.Time `json:"transaction_date" gorm:"column:transaction_date;type:date"`
+	TransactedAt    *time.Time `json:"transacted_at" gorm:"column:transacted_at"`
+	Currency        *string    `json:"currency" gorm:"column:currency"`
+	AmountMinor     *int64     `json:"amount_minor" gorm:"column:amount_minor"`
+	FeeMinor        *int64     `json:"fee_minor" gorm:"column:fee_minor"`
+	// Institution code of the bank or e-wallet detected as the issuer, null when none was
+	Institution *string   `json:"institution" gorm:"column:institution"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (Receipt) TableName() string {
+	return "receipts"
+}
@@ -1,19 +1,87 @@
 package model
 
+import "time"
+
+// ReceiptTransaction the binding tags are the rules an extracted receipt is validated with,
+// a missing value is left empty by the LLM and only breaks the rules of amount and fee
 type ReceiptTransaction struct {
 	TransactionID   string  `json:"transaction_id"`
-	Amount          float64 `json:"amount"`
-	Currency        string  `json:"currency"`
-	Date            string  `json:"date"`
+	Amount          float64 `json:"amount" binding:"gt=0"`
+	Currency        string  `json:"currency" binding:"omitempty,currency|iso4217"`
+	Date            string  `json:"date" binding:"omitempty,receipt_date"`
 	Time            string  `json:"time"`
 	SenderName      string  `json:"sender_name"`
-	SenderAccount   string  `json:"sender_account"`
+	SenderAccount   string  `json:"sender_account" binding:"omitempty,account_number=BankName"`
 	ReceiverName    string  `json:"receiver_name"`
-	ReceiverAccount string  `json:"receiver_account"`
+	ReceiverAccount string  `json:"receiver_account" binding:"omitempty,account_number"`
 	BankName        string  `json:"bank_name"`
 	TransactionType string  `json:"transaction_type"`
 	Reference       string  `json:"reference"`
 	Status          string  `json:"status"`
-	Fee             float64 `json:"fee"`
+	Fee             float64 `json:"fee" binding:"gte=0,ltefield=Amount"`
 	Description     string  `json:"description"`
 }
+
+// Violation a rule an extracted field breaks
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Money an amount in the minor unit of its currency, 15000000 with exponent 2 is 150000.00
+type Money struct {
+	MinorUnits int64  `json:"minor_units"`
+	Exponent   int    `json:"exponent"`
+	Currency   string `json:"currency"`
+	// Original the amount as extracted
+	Original string `json:"original"`
+}
+
+// NormalizedReceipt typed values of the free-form fields of a receipt, a field is nil when the
+// extracted value doesn't parse. The extracted values are kept in ReceiptTransaction
+type NormalizedReceipt struct {
+	// Date the date of the receipt in ISO 8601, 2025-05-12
+	Date *string `json:"date"`
+	// Timestamp the date and time of the receipt in RFC 3339, in its timezone when it prints one
+	// (WIB, WITA, WIT or an offset) and in OCR_DEFAULT_TIMEZONE otherwise
+	Timestamp *time.Time `json:"timestamp"`
+	// Currency the ISO 4217 code, OCR_DEFAULT_CURRENCY when the receipt prints none
+	Currency string `json:"currency"`
+	Amount   *Money `json:"amount"`
+	Fee      *Money `json:"fee"`
+}
+
+// Institution the bank or e-wallet detected as the issuer of a receipt
+type Institution struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	SWIFT  string `json:"swift,omitempty"`
+	BICode string `json:"bi_code,omitempty"`
+	// DetectedBy text when its name is printed on the receipt, logo when its logo is
+	DetectedBy string `json:"detected_by"`
+}
+
+// ReceiptResult a receipt as clients get it, Warnings are the rules it still breaks after the repair.
+// Institution is omitted when the issuer isn't a registered bank or e-wallet
+type ReceiptResult struct {
+	ReceiptTransaction
+	Normalized  NormalizedReceipt `json:"normalized"`
+	Institution *Institution      `json:"institution,omitempty"`
+	Warnings    []Violation       `json:"warnings,omitempty"`
+}
+
+// Extraction a receipt with the OCR text and the prompt version it was extracted with
+type Extraction struct {
+	Text          string
+	PromptVersion string
+	Receipt       *ReceiptTransaction
+	// RepairPromptVersion version of the repair prompt Receipt was corrected with, empty when the
+	// first result was kept
+	RepairPromptVersion string
+	// Warnings the validation rules Receipt still breaks
+	Warnings   []Violation
+	Normalized NormalizedReceipt
+	// Institution the detected issuer, nil when none was
+	Institution *Institution
+}
@@ -1,11 +1,24 @@
 package port
 
-import "context"
+import (
+	"context"
+	"rest-app/config"
+	"rest-app/internal/app/ocr/model"
+)
 
 type IHuggingFaceHTTP interface {
-	ProceedTxtToJSONGeneratorPrompt(ctx context.Context, txtTarget string) (string, error)
+	ProceedTxtToJSONGeneratorPrompt(ctx context.Context, prompt string) (string, error)
+	Ping(ctx context.Context) error
+	SetConfig(conf config.HuggingFaceAPIConf)
 }
 
 type IGoogleAIHTTP interface {
-	ProceedTxtToJSONGeneratorPrompt(ctx context.Context, txtTarget string) ([]byte, error)
+	ProceedTxtToJSONGeneratorPrompt(ctx context.Context, prompt string) ([]byte, error)
+	Ping(ctx context.Context) error
+	SetConfig(conf config.GoogleAIAPIConf)
+}
+
+type IReceiptRepository interface {
+	Create(ctx context.Context, receipt *model.Receipt) error
+	GetByID(ctx context.Context, id string) (*model.Receipt, error)
 }
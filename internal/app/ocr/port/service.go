@@ -2,9 +2,14 @@ package port
 
 import (
 	"context"
+	"rest-app/config"
 	"rest-app/internal/app/ocr/model"
 )
 
 type IOCRService interface {
-	ReceiptDataGenerator(ctx context.Context, imgBytes []byte) (*model.ReceiptTransaction, error)
+	// ReceiptDataGenerator the extracted receipt with the validation rules it breaks
+	ReceiptDataGenerator(ctx context.Context, imgBytes []byte) (*model.ReceiptResult, error)
+	// Extract is ReceiptDataGenerator keeping the OCR text the receipt was extracted from
+	Extract(ctx context.Context, imgBytes []byte) (*model.Extraction, error)
+	SetConfig(conf config.OCRConf)
 }
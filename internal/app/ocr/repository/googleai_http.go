@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"rest-app/config"
 	"rest-app/internal/app/ocr/port"
+	"strconv"
+	"sync/atomic"
 
+	"rest-app/pkg/constants"
 	"rest-app/pkg/httpclient"
+	"rest-app/pkg/metrics"
 )
 
 type GenerationConfig struct {
@@ -53,40 +57,45 @@ type GoogleTextGenerationRequest struct {
 	GenerationConfig *GenerationConfig `json:"generationConfig,omitempty"`
 }
 
+type UsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
 type GoogleTextGenerationResponse struct {
-	Candidates    []Candidate `json:"candidates"` // Fixed: Use Candidate struct
-	UsageMetadata interface{} `json:"usageMetadata,omitempty"`
-	ModelVersion  string      `json:"modelVersion,omitempty"`
-	ResponseId    string      `json:"responseId,omitempty"`
+	Candidates    []Candidate    `json:"candidates"` // Fixed: Use Candidate struct
+	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
+	ModelVersion  string         `json:"modelVersion,omitempty"`
+	ResponseId    string         `json:"responseId,omitempty"`
 }
 
 type googleaiTextGenerationHTTP struct {
-	conf       *config.GoogleAIAPIConf
+	conf       atomic.Pointer[config.GoogleAIAPIConf]
 	httpClient *httpclient.RestClient
 }
 
 func NewGoogleAIHTTP(conf *config.GoogleAIAPIConf, httpClient *httpclient.RestClient) port.IGoogleAIHTTP {
-	return &googleaiTextGenerationHTTP{
-		conf:       conf,
+	h := &googleaiTextGenerationHTTP{
 		httpClient: httpClient,
 	}
+	h.conf.Store(conf)
+	return h
 }
 
-func (h *googleaiTextGenerationHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context.Context, txtTarget string) ([]byte, error) {
-
-	const rules = `
-	Rules:
-		- Ensure the JSON matches the provided format exactly
-		- Use empty string "" for missing text fields
-		- Use 0.0 for missing numeric fields
-		- Extract amounts as numbers without currency symbols
-		- Remove any markdown code blocks or backticks from the output
-	`
+// SetConfig swaps the API settings used by the next calls
+func (h *googleaiTextGenerationHTTP) SetConfig(conf config.GoogleAIAPIConf) {
+	h.conf.Store(&conf)
+}
 
-	prompt := fmt.Sprintf("Parse this text below into JSON:%s \n and rules is %s", txtTarget, rules)
+// ProceedTxtToJSONGeneratorPrompt sends the rendered prompt, the response schema constrains the result
+func (h *googleaiTextGenerationHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context.Context, prompt string) ([]byte, error) {
+	conf := h.conf.Load()
 
+	// the key goes in a header rather than the query string so it doesn't end up in traces
 	headers := map[string]string{
-		"Content-Type": "application/json",
+		"Content-Type":   "application/json",
+		"x-goog-api-key": conf.APIToken,
 	}
 
 	reqPayload := GoogleTextGenerationRequest{
@@ -171,13 +180,15 @@ func (h *googleaiTextGenerationHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context
 		},
 	}
 
-	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", h.conf.URL, h.conf.Model, h.conf.APIToken)
-	resp, err := h.httpClient.Post(url, reqPayload, headers)
+	url := fmt.Sprintf("%s/models/%s:generateContent", conf.URL, conf.Model)
+	resp, err := h.httpClient.Post(ctx, url, reqPayload, headers)
 	if err != nil {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_GOOGLEAI, "http").Inc()
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 
 	if resp.StatusCode() >= 400 {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_GOOGLEAI, strconv.Itoa(resp.StatusCode())).Inc()
 		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode(), string(resp.Body()))
 	}
 
@@ -185,10 +196,14 @@ func (h *googleaiTextGenerationHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context
 
 	err = json.Unmarshal(resp.Body(), &finalResp)
 	if err != nil {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_GOOGLEAI, "decode").Inc()
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	h.recordUsage(finalResp, conf.Model)
+
 	if len(finalResp.Candidates) == 0 || len(finalResp.Candidates[0].Content.Parts) == 0 {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_GOOGLEAI, "empty").Inc()
 		return nil, fmt.Errorf("no valid content found in response")
 	}
 
@@ -197,6 +212,7 @@ func (h *googleaiTextGenerationHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context
 	var parsedJSON interface{}
 	err = json.Unmarshal([]byte(jsonText), &parsedJSON)
 	if err != nil {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_GOOGLEAI, "invalid_json").Inc()
 		return nil, fmt.Errorf("failed to parse JSON from response text: %w", err)
 	}
 
@@ -207,3 +223,39 @@ func (h *googleaiTextGenerationHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context
 
 	return finalRespBytes, nil
 }
+
+// recordUsage exports the token usage of a generation
+func (h *googleaiTextGenerationHTTP) recordUsage(resp GoogleTextGenerationResponse, fallbackModel string) {
+	if resp.UsageMetadata == nil {
+		return
+	}
+
+	model := resp.ModelVersion
+	if model == "" {
+		model = fallbackModel
+	}
+
+	metrics.LLMTokens.WithLabelValues(constants.LLM_PROVIDER_GOOGLEAI, model, "prompt").Add(float64(resp.UsageMetadata.PromptTokenCount))
+	metrics.LLMTokens.WithLabelValues(constants.LLM_PROVIDER_GOOGLEAI, model, "candidates").Add(float64(resp.UsageMetadata.CandidatesTokenCount))
+	metrics.LLMTokens.WithLabelValues(constants.LLM_PROVIDER_GOOGLEAI, model, "total").Add(float64(resp.UsageMetadata.TotalTokenCount))
+}
+
+// Ping checks the API is reachable and the configured model and token are valid
+func (h *googleaiTextGenerationHTTP) Ping(ctx context.Context) error {
+	conf := h.conf.Load()
+	headers := map[string]string{
+		"x-goog-api-key": conf.APIToken,
+	}
+
+	url := fmt.Sprintf("%s/models/%s", conf.URL, conf.Model)
+	resp, err := h.httpClient.Get(ctx, url, headers)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+
+	if resp.StatusCode() >= 400 {
+		return fmt.Errorf("API request failed with status %d", resp.StatusCode())
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rest-app/config"
+	"rest-app/pkg/httpclient"
+)
+
+// replayClient serves the committed cassette of client, never the network
+func replayClient(t *testing.T, client string) *httpclient.RestClient {
+	t.Helper()
+	path := filepath.Join("..", "..", "..", "..", "testdata", "cassettes", client+".json")
+	return httpclient.NewRestClient(5*time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		httpclient.WithCassette(httpclient.CassetteReplay, path))
+}
+
+func newReplayedGoogleAI(t *testing.T) *googleaiTextGenerationHTTP {
+	return NewGoogleAIHTTP(&config.GoogleAIAPIConf{
+		URL:      "https://generativelanguage.googleapis.com/v1beta",
+		APIToken: "test-token",
+		Model:    "gemini-2.0-flash",
+	}, replayClient(t, "googleai")).(*googleaiTextGenerationHTTP)
+}
+
+func TestGoogleAIProceedTxtToJSONGeneratorPrompt(t *testing.T) {
+	res, err := newReplayedGoogleAI(t).ProceedTxtToJSONGeneratorPrompt(context.Background(), "extract the receipt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var receipt map[string]interface{}
+	if err := json.Unmarshal(res, &receipt); err != nil {
+		t.Fatalf("result is not JSON: %v: %s", err, res)
+	}
+	if receipt["transaction_id"] != "TRX20250112093015" || receipt["amount"] != float64(150000) {
+		t.Errorf("unexpected receipt %s", res)
+	}
+}
+
+func TestGoogleAIPing(t *testing.T) {
+	if err := newReplayedGoogleAI(t).Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"rest-app/config"
 	"rest-app/internal/app/ocr/port"
+	"rest-app/pkg/constants"
 	"rest-app/pkg/httpclient"
+	"rest-app/pkg/metrics"
+	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 type HuggingFaceRequest struct {
@@ -20,50 +25,29 @@ type HuggingFaceResponse []struct {
 }
 
 type huggingFaceHTTP struct {
-	conf       *config.HuggingFaceAPIConf
+	conf       atomic.Pointer[config.HuggingFaceAPIConf]
 	httpClient *httpclient.RestClient
 }
 
 func NewHuggingFaceHTTP(conf *config.HuggingFaceAPIConf, httpClient *httpclient.RestClient) port.IHuggingFaceHTTP {
-	return &huggingFaceHTTP{
-		conf:       conf,
+	h := &huggingFaceHTTP{
 		httpClient: httpClient,
 	}
+	h.conf.Store(conf)
+	return h
 }
 
-func (h *huggingFaceHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context.Context, txtTarget string) (string, error) {
-	const jsonFormat = `{
-		"transaction_id": "",
-		"amount": 0.0,
-		"currency": "",
-		"date": "",
-		"time": "",
-		"sender_name": "",
-		"sender_account": "",
-		"receiver_name": "",
-		"receiver_account": "",
-		"bank_name": "",
-		"transaction_type": "",
-		"reference": "",
-		"status": "",
-		"fee": 0.0,
-		"description": ""
-	}`
-
-	const rules = `
-	Rules:
-		- Return ONLY the JSON object, no other text or explanation including the prompt
-		- Ensure the JSON matches the provided format exactly
-		- Use empty string "" for missing text fields
-		- Use 0.0 for missing numeric fields
-		- Extract amounts as numbers without currency symbols
-		- Remove any markdown code blocks or backticks from the output
-	`
-
-	prompt := fmt.Sprintf("Parse this text below into JSON:%s \n with format %s \n and rules is %s", txtTarget, jsonFormat, rules)
+// SetConfig swaps the API settings used by the next calls
+func (h *huggingFaceHTTP) SetConfig(conf config.HuggingFaceAPIConf) {
+	h.conf.Store(&conf)
+}
+
+// ProceedTxtToJSONGeneratorPrompt sends the rendered prompt, which has to describe the expected JSON itself
+func (h *huggingFaceHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context.Context, prompt string) (string, error) {
+	conf := h.conf.Load()
 
 	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %s", h.conf.APIToken),
+		"Authorization": fmt.Sprintf("Bearer %s", conf.APIToken),
 		"Content-Type":  "application/json",
 	}
 
@@ -77,22 +61,26 @@ func (h *huggingFaceHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context.Context, t
 		// },
 	}
 
-	url := fmt.Sprintf("%s/models/%s", strings.TrimSuffix(h.conf.URL, "/"), h.conf.Model)
-	resp, err := h.httpClient.Post(url, reqPayload, headers)
+	url := fmt.Sprintf("%s/models/%s", strings.TrimSuffix(conf.URL, "/"), conf.Model)
+	resp, err := h.httpClient.Post(ctx, url, reqPayload, headers)
 	if err != nil {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_HUGGINGFACE, "http").Inc()
 		return "", fmt.Errorf("HTTP request failed: %w", err)
 	}
 
 	if resp.StatusCode() >= 400 {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_HUGGINGFACE, strconv.Itoa(resp.StatusCode())).Inc()
 		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode(), string(resp.Body()))
 	}
 
 	var apiResponse HuggingFaceResponse
 	if err := json.Unmarshal(resp.Body(), &apiResponse); err != nil {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_HUGGINGFACE, "decode").Inc()
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if len(apiResponse) == 0 || apiResponse[0].GeneratedText == "" {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_HUGGINGFACE, "empty").Inc()
 		return "", fmt.Errorf("empty response from API")
 	}
 
@@ -103,8 +91,29 @@ func (h *huggingFaceHTTP) ProceedTxtToJSONGeneratorPrompt(ctx context.Context, t
 	// Validate it's valid JSON
 	var js map[string]interface{}
 	if err := json.Unmarshal([]byte(generatedText), &js); err != nil {
+		metrics.LLMErrors.WithLabelValues(constants.LLM_PROVIDER_HUGGINGFACE, "invalid_json").Inc()
 		return "", fmt.Errorf("API response is not valid JSON: %w", err)
 	}
 
 	return generatedText, nil
 }
+
+// Ping checks the API is reachable and the token is accepted
+func (h *huggingFaceHTTP) Ping(ctx context.Context) error {
+	conf := h.conf.Load()
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", conf.APIToken),
+	}
+
+	url := fmt.Sprintf("%s/models/%s", strings.TrimSuffix(conf.URL, "/"), conf.Model)
+	resp, err := h.httpClient.Get(ctx, url, headers)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+
+	if resp.StatusCode() >= 500 || resp.StatusCode() == http.StatusUnauthorized || resp.StatusCode() == http.StatusForbidden {
+		return fmt.Errorf("API request failed with status %d", resp.StatusCode())
+	}
+
+	return nil
+}
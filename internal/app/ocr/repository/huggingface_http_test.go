@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"rest-app/config"
+)
+
+func newReplayedHuggingFace(t *testing.T) *huggingFaceHTTP {
+	return NewHuggingFaceHTTP(&config.HuggingFaceAPIConf{
+		URL:      "https://api-inference.huggingface.co",
+		APIToken: "test-token",
+		Model:    "mistralai/Mistral-7B-Instruct-v0.3",
+	}, replayClient(t, "huggingface")).(*huggingFaceHTTP)
+}
+
+func TestHuggingFaceProceedTxtToJSONGeneratorPrompt(t *testing.T) {
+	res, err := newReplayedHuggingFace(t).ProceedTxtToJSONGeneratorPrompt(context.Background(), "extract the receipt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var receipt map[string]interface{}
+	if err := json.Unmarshal([]byte(res), &receipt); err != nil {
+		t.Fatalf("result is not JSON: %v: %s", err, res)
+	}
+	if receipt["transaction_id"] != "TRX20250112093015" || receipt["amount"] != float64(150000) {
+		t.Errorf("unexpected receipt %s", res)
+	}
+}
+
+func TestHuggingFacePing(t *testing.T) {
+	if err := newReplayedHuggingFace(t).Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"rest-app/config/db"
+	"rest-app/internal/app/ocr/model"
+	"rest-app/internal/app/ocr/port"
+	"rest-app/pkg/transaction"
+)
+
+type receiptDB struct {
+	db *db.GormDB
+}
+
+func NewReceiptDB(db *db.GormDB) port.IReceiptRepository {
+	return &receiptDB{
+		db: db,
+	}
+}
+
+func (r *receiptDB) Create(ctx context.Context, receipt *model.Receipt) error {
+	return transaction.GetTrxContext(ctx, r.db).Create(receipt).Error
+}
+
+func (r *receiptDB) GetByID(ctx context.Context, id string) (*model.Receipt, error) {
+	var receipt model.Receipt
+
+	err := transaction.GetTrxContext(ctx, r.db).
+		Where("id = ?", id).
+		First(&receipt).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &receipt, nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"rest-app/internal/app/ocr/model"
+	"rest-app/pkg/bank"
+	"rest-app/pkg/logging"
+	"rest-app/pkg/metrics"
+	"rest-app/pkg/tracing"
+	"slices"
+	"strconv"
+	"strings"
+
+	promptModel "rest-app/internal/app/prompt/model"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gocv.io/x/gocv"
+)
+
+// issuer the institution detected as the issuer of a receipt and the values its layout labels,
+// the zero value when none was detected
+type issuer struct {
+	detection bank.Detection
+	fields    bank.Fields
+}
+
+// detectIssuer looks for the institution in text first, the logo of the image is only matched
+// when the text doesn't name it
+func (o *ocr) detectIssuer(ctx context.Context, imgBytes []byte, text string) issuer {
+	ctx, span := tracing.Start(ctx, "ocr.detectIssuer")
+	defer span.End()
+
+	detection, ok := bank.DetectText(text)
+	if !ok && o.Logos.Loaded() {
+		detection, ok = o.matchLogo(ctx, imgBytes)
+	}
+	if !ok {
+		metrics.InstitutionDetections.WithLabelValues("none", "").Inc()
+		return issuer{}
+	}
+
+	i := issuer{detection: detection, fields: detection.Institution.Parse(text)}
+	metrics.InstitutionDetections.WithLabelValues(detection.Institution.Code, detection.By).Inc()
+	span.SetAttributes(
+		attribute.String("receipt.institution", detection.Institution.Code),
+		attribute.String("receipt.institution_detected_by", detection.By),
+		attribute.Int("receipt.layout_fields", len(i.fields)))
+	// the values are receipt contents, only which fields were read is logged
+	logging.FromContext(ctx).Debug("receipt issuer detected",
+		slog.String("institution", detection.Institution.Code),
+		slog.String("by", detection.By),
+		slog.Float64("score", detection.Score),
+		slog.Any("fields", slices.Sorted(maps.Keys(i.fields))))
+	return i
+}
+
+// matchLogo decodes the image again in grayscale, the preprocessed one is binarized and has lost the logo
+func (o *ocr) matchLogo(ctx context.Context, imgBytes []byte) (bank.Detection, bool) {
+	_, span := tracing.Start(ctx, "ocr.matchLogo")
+	defer span.End()
+
+	cfg, err := o.checkImage(imgBytes)
+	if err != nil {
+		return bank.Detection{}, false
+	}
+	img, err := decodeImage(cfg, imgBytes)
+	if err != nil || img.Empty() {
+		return bank.Detection{}, false
+	}
+	defer img.Close()
+
+	gray := gocv.NewMat()
+	defer gray.Close()
+	gocv.CvtColor(img, &gray, gocv.ColorBGRToGray)
+
+	return o.Logos.Match(gray, o.conf.Load().LogoMinScore)
+}
+
+// name of the institution, the prompt tells it to the LLM
+func (i issuer) name() string {
+	if i.detection.Institution == nil {
+		return ""
+	}
+	return i.detection.Institution.Name
+}
+
+// hints the layout fields in a stable order
+func (i issuer) hints() []promptModel.Hint {
+	hints := make([]promptModel.Hint, 0, len(i.fields))
+	for field, value := range i.fields {
+		hints = append(hints, promptModel.Hint{Field: field, Value: value})
+	}
+	slices.SortFunc(hints, func(a, b promptModel.Hint) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return hints
+}
+
+// institution as results show it
+func (i issuer) institution() *model.Institution {
+	institution := i.detection.Institution
+	if institution == nil {
+		return nil
+	}
+	return &model.Institution{
+		Code:       institution.Code,
+		Name:       institution.Name,
+		Kind:       institution.Kind,
+		SWIFT:      institution.SWIFT,
+		BICode:     institution.BICode,
+		DetectedBy: i.detection.By,
+	}
+}
+
+// complete sets bank_name of the receipt JSON data to the institution and fills the fields the
+// LLM left empty with the values of the layout. data is returned as is when nothing changes or
+// when it isn't a JSON object
+func (i issuer) complete(data []byte) []byte {
+	if i.detection.Institution == nil {
+		return data
+	}
+
+	var receipt map[string]json.RawMessage
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return data
+	}
+
+	changed := false
+	if name, _ := json.Marshal(i.detection.Institution.Name); string(receipt["bank_name"]) != string(name) {
+		receipt["bank_name"] = name
+		changed = true
+	}
+	for field, value := range i.fields {
+		if !isEmptyJSON(receipt[field]) {
+			continue
+		}
+		var raw json.RawMessage
+		switch field {
+		case "amount", "fee":
+			// a decimal literal is a JSON number
+			raw = json.RawMessage(value)
+		default:
+			raw, _ = json.Marshal(value)
+		}
+		if json.Valid(raw) {
+			receipt[field] = raw
+			changed = true
+		}
+	}
+	if !changed {
+		return data
+	}
+
+	completed, err := json.Marshal(receipt)
+	if err != nil {
+		return data
+	}
+	return completed
+}
+
+// isEmptyJSON a missing value, null, a blank string or 0, what the prompt asks the LLM to return
+// for a value it doesn't find
+func isEmptyJSON(raw json.RawMessage) bool {
+	value := strings.TrimSpace(string(raw))
+	if value == "" || value == "null" {
+		return true
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s) == ""
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	return err == nil && n == 0
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"encoding/json"
+	"regexp"
+	"rest-app/config"
+	"rest-app/internal/app/ocr/model"
+	"rest-app/pkg/normalize"
+	"strings"
+	"time"
+)
+
+// isoCode an ISO 4217 code normalize.Currency doesn't list, the validation accepted it
+var isoCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// normalizeReceipt the typed values of receipt. data is the JSON it was decoded from, amounts are
+// read from its number literals so they don't go through a float
+func normalizeReceipt(conf *config.OCRConf, receipt *model.ReceiptTransaction, data []byte) model.NormalizedReceipt {
+	var normalized model.NormalizedReceipt
+
+	loc, err := time.LoadLocation(conf.DefaultTimezone)
+	if err != nil {
+		// checked when the configuration is loaded
+		loc = time.UTC
+	}
+	timestamp, date, ok := normalize.Timestamp(receipt.Date, receipt.Time, loc)
+	if ok {
+		normalized.Timestamp = &timestamp
+	}
+	if !date.IsZero() {
+		day := date.Format(time.DateOnly)
+		normalized.Date = &day
+	}
+
+	normalized.Currency = conf.DefaultCurrency
+	if currency := strings.TrimSpace(receipt.Currency); currency != "" {
+		if code, ok := normalize.Currency(currency); ok {
+			normalized.Currency = code
+		} else if code := strings.ToUpper(currency); isoCode.MatchString(code) {
+			normalized.Currency = code
+		} else {
+			normalized.Currency = ""
+		}
+	}
+
+	var amounts struct {
+		Amount json.Number `json:"amount"`
+		Fee    json.Number `json:"fee"`
+	}
+	// data was decoded into receipt already, it is valid JSON
+	_ = json.Unmarshal(data, &amounts)
+	normalized.Amount = money(amounts.Amount, normalized.Currency)
+	normalized.Fee = money(amounts.Fee, normalized.Currency)
+
+	return normalized
+}
+
+// money nil without an amount or a currency
+func money(amount json.Number, currency string) *model.Money {
+	if amount == "" || currency == "" {
+		return nil
+	}
+
+	exponent := normalize.CurrencyExponent(currency)
+	minor, err := normalize.MinorUnits(amount.String(), exponent)
+	if err != nil {
+		return nil
+	}
+	return &model.Money{MinorUnits: minor, Exponent: exponent, Currency: currency, Original: amount.String()}
+}
@@ -1,91 +1,417 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
+	"image/png"
+	"log/slog"
+	"rest-app/config"
 	"rest-app/internal/app/ocr/model"
 	"rest-app/internal/app/ocr/port"
+	"rest-app/pkg/apperror"
+	"rest-app/pkg/bank/logo"
+	"rest-app/pkg/constants"
+	"rest-app/pkg/imageinfo"
+	"rest-app/pkg/logging"
+	"rest-app/pkg/metrics"
+	"rest-app/pkg/tesseract"
+	"rest-app/pkg/tracing"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/otiai10/gosseract/v2"
+	examplePort "rest-app/internal/app/example/port"
+	promptModel "rest-app/internal/app/prompt/model"
+	promptPort "rest-app/internal/app/prompt/port"
+	tenantPort "rest-app/internal/app/tenant/port"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gocv.io/x/gocv"
 )
 
+// minOCRTextLength shorter OCR results are too poor to be worth an LLM call
+const minOCRTextLength = 10
+
+// receiptFormat the empty receipt JSON prompts describe the expected result with
+var receiptFormat = func() string {
+	format, _ := json.MarshalIndent(model.ReceiptTransaction{}, "", "  ")
+	return string(format)
+}()
+
 type ocr struct {
-	OCRClient *gosseract.Client
-	//HuggingFaceRepo port.IHuggingFaceHTTP
-	GoogleAIRepo port.IGoogleAIHTTP
+	conf            atomic.Pointer[config.OCRConf]
+	OCRPool         *tesseract.Pool
+	HuggingFaceRepo port.IHuggingFaceHTTP
+	GoogleAIRepo    port.IGoogleAIHTTP
+	ReceiptRepo     port.IReceiptRepository
+	TenantService   tenantPort.ITenantService
+	PromptService   promptPort.IPromptService
+	ExampleService  examplePort.IExampleService
+	Logos           *logo.Templates
+
+	// readText reads the text of a receipt image, readImage outside of tests
+	readText func(ctx context.Context, imgBytes []byte) (string, error)
 }
 
-func NewOCRService(OCRClient *gosseract.Client, GoogleAIRepo port.IGoogleAIHTTP) port.IOCRService {
-	return &ocr{
-		OCRClient: OCRClient,
-		//HuggingFaceRepo: HuggingFaceRepo,
-		GoogleAIRepo: GoogleAIRepo,
+// NewOCRService HuggingFaceRepo and ReceiptRepo are optional and may be nil
+func NewOCRService(conf *config.OCRConf, OCRPool *tesseract.Pool, GoogleAIRepo port.IGoogleAIHTTP, HuggingFaceRepo port.IHuggingFaceHTTP, ReceiptRepo port.IReceiptRepository, TenantService tenantPort.ITenantService, PromptService promptPort.IPromptService, ExampleService examplePort.IExampleService, Logos *logo.Templates) port.IOCRService {
+	o := &ocr{
+		OCRPool:         OCRPool,
+		HuggingFaceRepo: HuggingFaceRepo,
+		GoogleAIRepo:    GoogleAIRepo,
+		ReceiptRepo:     ReceiptRepo,
+		TenantService:   TenantService,
+		PromptService:   PromptService,
+		ExampleService:  ExampleService,
+		Logos:           Logos,
 	}
+	o.readText = o.readImage
+	o.conf.Store(conf)
+	return o
 }
 
-func (o *ocr) ReceiptDataGenerator(ctx context.Context, imgBytes []byte) (*model.ReceiptTransaction, error) {
-
-	defer o.OCRClient.Close()
+// SetConfig swaps the image limits checked by the next requests
+func (o *ocr) SetConfig(conf config.OCRConf) {
+	o.conf.Store(&conf)
+}
 
-	var (
-		receiptData         model.ReceiptTransaction
-		optimizedImageBytes []byte
-		err                 error
-	)
+func (o *ocr) ReceiptDataGenerator(ctx context.Context, imgBytes []byte) (*model.ReceiptResult, error) {
+	extraction, err := o.Extract(ctx, imgBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReceiptResult{
+		ReceiptTransaction: *extraction.Receipt,
+		Normalized:         extraction.Normalized,
+		Institution:        extraction.Institution,
+		Warnings:           extraction.Warnings,
+	}, nil
+}
 
-	optimizedImageBytes, err = o.optimizeImageFromBytes(imgBytes)
+func (o *ocr) Extract(ctx context.Context, imgBytes []byte) (_ *model.Extraction, err error) {
+	ctx, span := tracing.Start(ctx, "ocr.ReceiptDataGenerator")
+	defer tracing.End(span, &err)
 
+	settings, err := o.TenantService.GetSettings(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to optimize image: %w", err)
+		return nil, err
+	}
+	if !settings.AllowsDocumentType(constants.DOCUMENT_TYPE_RECEIPT) {
+		return nil, model.ErrDocumentTypeNotAllowed
 	}
 
-	// Set the optimized image for OCR
-	err = o.OCRClient.SetImageFromBytes(optimizedImageBytes)
+	var receiptData model.ReceiptTransaction
+
+	text, err := o.readText(ctx, imgBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set optimized image: %w", err)
+		return nil, err
+	}
+	if len(strings.TrimSpace(text)) < minOCRTextLength {
+		return nil, model.ErrOCRLowQuality
 	}
 
-	text, err := o.OCRClient.Text()
+	// the text holds account numbers and names, the digest tells identical results apart
+	logging.FromContext(ctx).Debug("OCR result", slog.Int("text_length", len(text)), slog.String("text_sha256", digest(text)))
+
+	issuer := o.detectIssuer(ctx, imgBytes, text)
+
+	provider := settings.LLMProvider
+	if provider == "" {
+		provider = constants.LLM_PROVIDER_GOOGLEAI
+	}
+	prompt, err := o.PromptService.Render(ctx, constants.DOCUMENT_TYPE_RECEIPT, provider, promptModel.Data{
+		Text:        text,
+		LLMProvider: provider,
+		Format:      receiptFormat,
+		// Google AI is sent the response schema
+		StructuredOutput: provider == constants.LLM_PROVIDER_GOOGLEAI,
+		Examples:         o.selectExamples(ctx, text),
+		Institution:      issuer.name(),
+		Hints:            issuer.hints(),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("OCR processing failed: %w", err)
+		return nil, err
 	}
 
-	fmt.Println("OCR Result:", text)
-
 	// Parse generated text from OCR using AI for JSON Result
-	resByte, err := o.GoogleAIRepo.ProceedTxtToJSONGeneratorPrompt(ctx, text)
+	resByte, err := o.generateJSON(ctx, provider, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("AI Text processing failed: %w", err)
 	}
+	resByte = issuer.complete(resByte)
 
 	err = json.Unmarshal(resByte, &receiptData)
 	if err != nil {
-		return nil, fmt.Errorf("parsing AI Result Text failed: %w", err)
+		return nil, apperror.Wrap(apperror.UpstreamProvider, err, "LLM provider returned an unexpected result")
+	}
+
+	extraction := &model.Extraction{Text: text, PromptVersion: prompt.Version, Receipt: &receiptData, Institution: issuer.institution()}
+	if violations := validateReceipt(&receiptData); len(violations) > 0 {
+		var repairErr error
+		if resByte, repairErr = o.repair(ctx, provider, extraction, issuer, resByte, violations); repairErr != nil {
+			metrics.ExtractionRepairs.WithLabelValues("failed").Inc()
+			logging.FromContext(ctx).Warn("receipt repair failed, the first result is kept", slog.Any("error", repairErr))
+		}
+	}
+	extraction.Normalized = normalizeReceipt(o.conf.Load(), extraction.Receipt, resByte)
+
+	if o.ReceiptRepo != nil {
+		receipt, err := newReceipt(provider, extraction, resByte)
+		if err != nil {
+			return nil, err
+		}
+		err = o.ReceiptRepo.Create(ctx, receipt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store receipt: %w", err)
+		}
+	}
+
+	return extraction, nil
+}
+
+// newReceipt the stored copy of extraction, data is the JSON of its receipt. Fails when a
+// normalized value doesn't convert, a receipt is never stored with a zero date
+func newReceipt(provider string, extraction *model.Extraction, data []byte) (*model.Receipt, error) {
+	receipt := &model.Receipt{
+		DocumentType:        constants.DOCUMENT_TYPE_RECEIPT,
+		LLMProvider:         provider,
+		PromptVersion:       extraction.PromptVersion,
+		RepairPromptVersion: extraction.RepairPromptVersion,
+		OCRText:             extraction.Text,
+		Data:                data,
+		TransactedAt:        extraction.Normalized.Timestamp,
+	}
+	if extraction.Institution != nil {
+		receipt.Institution = &extraction.Institution.Code
+	}
+	if len(extraction.Warnings) > 0 {
+		receipt.Warnings, _ = json.Marshal(extraction.Warnings)
+	}
+
+	normalized := extraction.Normalized
+	if normalized.Date != nil {
+		day, err := time.Parse(time.DateOnly, *normalized.Date)
+		if err != nil {
+			return nil, fmt.Errorf("normalized date %q: %w", *normalized.Date, err)
+		}
+		receipt.TransactionDate = &day
+	}
+	if normalized.Currency != "" {
+		receipt.Currency = &normalized.Currency
+	}
+	if normalized.Amount != nil {
+		receipt.AmountMinor = &normalized.Amount.MinorUnits
+	}
+	if normalized.Fee != nil {
+		receipt.FeeMinor = &normalized.Fee.MinorUnits
+	}
+	return receipt, nil
+}
+
+// digest a short SHA-256 of text, to correlate logs without writing text
+func digest(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
+
+// selectExamples the few-shot examples closest to text, the extraction goes on without them
+// when the library can't be read
+func (o *ocr) selectExamples(ctx context.Context, text string) []promptModel.Example {
+	examples, err := o.ExampleService.Select(ctx, text)
+	if err != nil {
+		logging.FromContext(ctx).Warn("few-shot examples unavailable", slog.Any("error", err))
+	}
+
+	selected := make([]promptModel.Example, 0, len(examples))
+	for _, example := range examples {
+		selected = append(selected, promptModel.Example{Text: example.OCRText, JSON: string(example.Data)})
+		logging.FromContext(ctx).Debug("few-shot example", slog.String("example_id", example.ID), slog.String("bank_name", example.BankName))
+	}
+	return selected
+}
+
+// generateJSON sends the prompt to the tenant's LLM provider, provider failures are classified as upstream errors
+func (o *ocr) generateJSON(ctx context.Context, provider string, prompt *promptModel.Prompt) (_ []byte, err error) {
+	defer metrics.ObserveStage(metrics.StageLLM, time.Now())
+
+	ctx, span := tracing.Start(ctx, "ocr.generateJSON", trace.WithAttributes(
+		attribute.String("llm.provider", provider),
+		attribute.String("llm.prompt_version", prompt.Version)))
+	defer tracing.End(span, &err)
+
+	res, err := o.callProvider(ctx, provider, prompt.Text)
+	if err != nil && ctx.Err() == nil {
+		if _, ok := apperror.As(err); !ok {
+			err = apperror.Wrap(apperror.UpstreamProvider, err, "LLM provider request failed")
+		}
+	}
+	return res, err
+}
+
+func (o *ocr) callProvider(ctx context.Context, provider string, prompt string) ([]byte, error) {
+	switch provider {
+	case constants.LLM_PROVIDER_HUGGINGFACE:
+		if o.HuggingFaceRepo == nil {
+			return nil, apperror.New(apperror.Internal, fmt.Sprintf("llm provider %s is not configured", provider))
+		}
+		res, err := o.HuggingFaceRepo.ProceedTxtToJSONGeneratorPrompt(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(res), nil
+	case constants.LLM_PROVIDER_GOOGLEAI, "":
+		return o.GoogleAIRepo.ProceedTxtToJSONGeneratorPrompt(ctx, prompt)
+	default:
+		return nil, apperror.New(apperror.Internal, fmt.Sprintf("unknown llm provider %s", provider))
+	}
+}
+
+// readImage prepares the image then runs tesseract on it
+func (o *ocr) readImage(ctx context.Context, imgBytes []byte) (string, error) {
+	optimizedImageBytes, err := o.optimizeImageFromBytes(ctx, imgBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to optimize image: %w", err)
+	}
+	return o.recognizeText(ctx, optimizedImageBytes)
+}
+
+// recognizeText runs tesseract on a client borrowed from the pool
+func (o *ocr) recognizeText(ctx context.Context, imageBytes []byte) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "ocr.recognizeText")
+	defer tracing.End(span, &err)
+
+	waitStart := time.Now()
+	client, err := o.OCRPool.Acquire(ctx)
+	metrics.OCRPoolWait.Observe(time.Since(waitStart).Seconds())
+	if errors.Is(err, tesseract.ErrPoolClosed) {
+		return "", apperror.Wrap(apperror.Unavailable, err, "server is shutting down, retry the request")
+	}
+	if err != nil {
+		return "", fmt.Errorf("no OCR worker available: %w", err)
+	}
+	defer o.OCRPool.Release(client)
+
+	defer metrics.ObserveStage(metrics.StageTesseract, time.Now())
+
+	// Set the optimized image for OCR
+	err = client.SetImageFromBytes(imageBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to set optimized image: %w", err)
+	}
+
+	_, textSpan := tracing.Start(ctx, "tesseract.Text")
+	text, err := client.Text()
+	tracing.End(textSpan, &err)
+	if err != nil {
+		return "", fmt.Errorf("OCR processing failed: %w", err)
+	}
+
+	return text, nil
+}
+
+// checkImage sniffs the format from the content and enforces the dimension limits before anything is decoded
+func (o *ocr) checkImage(imageBytes []byte) (imageinfo.Config, error) {
+	cfg, err := imageinfo.DecodeConfig(imageBytes)
+	if errors.Is(err, imageinfo.ErrUnknownFormat) {
+		return cfg, apperror.New(apperror.UnsupportedMedia, "unsupported file type, send a JPEG, PNG, WebP or TIFF image")
+	}
+	if err != nil {
+		return cfg, apperror.Wrap(apperror.UnsupportedMedia, err, "image could not be decoded")
+	}
+
+	conf := o.conf.Load()
+	if !slices.Contains(conf.AllowedImageFormats, string(cfg.Format)) {
+		return cfg, apperror.New(apperror.UnsupportedMedia, fmt.Sprintf("%s images are not accepted", cfg.Format))
+	}
+
+	limits := imageinfo.Limits{
+		MaxWidth:  conf.MaxImageWidth,
+		MaxHeight: conf.MaxImageHeight,
+		MaxPixels: conf.MaxImagePixels,
+	}
+	if maxPixels, ok := conf.MaxImagePixelsByFormat[string(cfg.Format)]; ok {
+		limits.MaxPixels = int64(maxPixels)
+	}
+	if err := limits.Check(cfg); err != nil {
+		return cfg, apperror.New(apperror.PayloadTooLarge, err.Error())
+	}
+
+	return cfg, nil
+}
+
+// decodeImage decodes JPEG and PNG with OpenCV, the other formats with the Go decoders first
+func decodeImage(cfg imageinfo.Config, imageBytes []byte) (gocv.Mat, error) {
+	switch cfg.Format {
+	case imageinfo.JPEG, imageinfo.PNG:
+		return gocv.IMDecode(imageBytes, gocv.IMReadColor)
+	}
+
+	img, err := imageinfo.Decode(imageBytes)
+	if errors.Is(err, imageinfo.ErrNoDecoder) {
+		return gocv.Mat{}, apperror.New(apperror.UnsupportedMedia, fmt.Sprintf("%s decoding is not available on this server", cfg.Format))
+	}
+	if err != nil {
+		return gocv.Mat{}, err
 	}
 
-	return &receiptData, nil
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return gocv.Mat{}, err
+	}
+	return gocv.IMDecode(buf.Bytes(), gocv.IMReadColor)
 }
 
-// optimizeImageFromBytes loads image from byte array and applies preprocessing
-func (o *ocr) optimizeImageFromBytes(imageBytes []byte) ([]byte, error) {
+// optimizeImageFromBytes checks and loads image from byte array and applies preprocessing
+func (o *ocr) optimizeImageFromBytes(ctx context.Context, imageBytes []byte) ([]byte, error) {
+	cfg, err := o.checkImage(imageBytes)
+	if err != nil {
+		return nil, err
+	}
+
 	// Decode image from bytes
-	img, err := gocv.IMDecode(imageBytes, gocv.IMReadColor)
+	var img gocv.Mat
+	decodeStart := time.Now()
+	step(ctx, "decode", func() {
+		img, err = decodeImage(cfg, imageBytes)
+	})
+	metrics.ObserveStage(metrics.StageDecode, decodeStart)
+	if _, ok := apperror.As(err); ok {
+		return nil, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("unable to decode image from bytes: %w", err)
+		return nil, apperror.Wrap(apperror.UnsupportedMedia, err, "image could not be decoded")
 	}
 	if img.Empty() {
-		return nil, fmt.Errorf("decoded image is empty")
+		return nil, apperror.New(apperror.UnsupportedMedia, "image could not be decoded")
 	}
 	defer img.Close()
 
-	return o.preprocessImage(img)
+	return o.preprocessImage(ctx, img)
+}
+
+// step runs an image processing step in its own span
+func step(ctx context.Context, name string, fn func()) {
+	_, span := tracing.Start(ctx, "preprocess."+name)
+	defer span.End()
+
+	fn()
 }
 
 // preprocessImage applies various image optimization techniques for better OCR
-func (o *ocr) preprocessImage(src gocv.Mat) ([]byte, error) {
+func (o *ocr) preprocessImage(ctx context.Context, src gocv.Mat) (_ []byte, err error) {
+	defer metrics.ObserveStage(metrics.StagePreprocess, time.Now())
+
+	ctx, span := tracing.Start(ctx, "ocr.preprocessImage")
+	defer tracing.End(span, &err)
+
 	// Create working matrices
 	gray := gocv.NewMat()
 	blurred := gocv.NewMat()
@@ -101,47 +427,64 @@ func (o *ocr) preprocessImage(src gocv.Mat) ([]byte, error) {
 	defer denoised.Close()
 	defer resized.Close()
 
+	preprocess := o.conf.Load().Preprocess
+
 	// Step 1: Resize image if too large (improve processing speed and sometimes accuracy)
-	if src.Cols() > 2000 || src.Rows() > 2000 {
+	if maxDimension := preprocess.MaxDimension; src.Cols() > maxDimension || src.Rows() > maxDimension {
 		newWidth := src.Cols()
 		newHeight := src.Rows()
 
 		// Scale down while maintaining aspect ratio
 		if src.Cols() > src.Rows() {
-			newWidth = 2000
-			newHeight = int(float64(src.Rows()) * (2000.0 / float64(src.Cols())))
+			newWidth = maxDimension
+			newHeight = int(float64(src.Rows()) * (float64(maxDimension) / float64(src.Cols())))
 		} else {
-			newHeight = 2000
-			newWidth = int(float64(src.Cols()) * (2000.0 / float64(src.Rows())))
+			newHeight = maxDimension
+			newWidth = int(float64(src.Cols()) * (float64(maxDimension) / float64(src.Rows())))
 		}
 
-		gocv.Resize(src, &resized, image.Pt(newWidth, newHeight), 0, 0, gocv.InterpolationLinear)
+		step(ctx, "resize", func() {
+			gocv.Resize(src, &resized, image.Pt(newWidth, newHeight), 0, 0, gocv.InterpolationLinear)
+		})
 		src = resized.Clone() // Use resized image for further processing
 		defer src.Close()
 	}
 
 	// Step 2: Convert to grayscale
-	gocv.CvtColor(src, &gray, gocv.ColorBGRToGray)
+	step(ctx, "grayscale", func() {
+		gocv.CvtColor(src, &gray, gocv.ColorBGRToGray)
+	})
 
 	// Step 3: Apply Gaussian blur to reduce noise
-	gocv.GaussianBlur(gray, &blurred, image.Pt(3, 3), 0, 0, gocv.BorderDefault)
+	step(ctx, "gaussian_blur", func() {
+		gocv.GaussianBlur(gray, &blurred, image.Pt(preprocess.BlurKernel, preprocess.BlurKernel), 0, 0, gocv.BorderDefault)
+	})
 
 	// Step 4: Apply adaptive threshold for better text extraction
 	// This works better than simple threshold for receipts with varying lighting
-	gocv.AdaptiveThreshold(blurred, &thresh, 255, gocv.AdaptiveThresholdMean, gocv.ThresholdBinary, 11, 2)
+	step(ctx, "adaptive_threshold", func() {
+		gocv.AdaptiveThreshold(blurred, &thresh, 255, gocv.AdaptiveThresholdMean, gocv.ThresholdBinary, preprocess.ThresholdBlockSize, float32(preprocess.ThresholdC))
+	})
 
 	// Step 5: Morphological operations to clean up the image
-	kernel := gocv.GetStructuringElement(gocv.MorphRect, image.Pt(2, 2))
+	kernel := gocv.GetStructuringElement(gocv.MorphRect, image.Pt(preprocess.MorphKernel, preprocess.MorphKernel))
 	defer kernel.Close()
 
 	// Opening operation (erosion followed by dilation) to remove noise
-	gocv.MorphologyEx(thresh, &morphed, gocv.MorphOpen, kernel)
+	step(ctx, "morphology_open", func() {
+		gocv.MorphologyEx(thresh, &morphed, gocv.MorphOpen, kernel)
+	})
 
 	// Step 6: Optional - Apply median blur for additional noise reduction
-	gocv.MedianBlur(morphed, &denoised, 3)
+	step(ctx, "median_blur", func() {
+		gocv.MedianBlur(morphed, &denoised, preprocess.MedianKernel)
+	})
 
 	// Step 7: Encode the processed image back to bytes
-	buf, err := gocv.IMEncode(".png", denoised) // Use PNG for lossless compression
+	var buf *gocv.NativeByteBuffer
+	step(ctx, "encode", func() {
+		buf, err = gocv.IMEncode(".png", denoised) // Use PNG for lossless compression
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode processed image: %w", err)
 	}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rest-app/config"
+	"rest-app/pkg/bank/logo"
+	"rest-app/pkg/constants"
+	"rest-app/pkg/httpclient"
+	"rest-app/pkg/validations"
+	"rest-app/prompts"
+
+	exampleService "rest-app/internal/app/example/service"
+	"rest-app/internal/app/ocr/model"
+	ocrRepo "rest-app/internal/app/ocr/repository"
+	promptService "rest-app/internal/app/prompt/service"
+	tenantModel "rest-app/internal/app/tenant/model"
+)
+
+// receiptText what tesseract reads on the receipt the cassettes were recorded for
+const receiptText = `TRANSFER BERHASIL
+12 Jan 2025 09:30:15
+No. Ref TRX20250112093015
+Dari BUDI SANTOSO 1234567890
+Ke SITI AMINAH 0987654321
+Jumlah Rp 150.000`
+
+func TestMain(m *testing.M) {
+	validations.InitStructValidation()
+	os.Exit(m.Run())
+}
+
+type stubTenantService struct{ settings tenantModel.Tenant }
+
+func (s stubTenantService) GetSettings(context.Context) (*tenantModel.Tenant, error) {
+	return &s.settings, nil
+}
+
+func (s stubTenantService) AuthenticateAPIKey(context.Context, string) (*tenantModel.APIKey, error) {
+	return nil, nil
+}
+
+type memoryReceiptRepository struct{ created []*model.Receipt }
+
+func (r *memoryReceiptRepository) Create(_ context.Context, receipt *model.Receipt) error {
+	r.created = append(r.created, receipt)
+	return nil
+}
+
+func (r *memoryReceiptRepository) GetByID(context.Context, string) (*model.Receipt, error) {
+	return nil, nil
+}
+
+func replayClient(client string) *httpclient.RestClient {
+	path := filepath.Join("..", "..", "..", "..", "testdata", "cassettes", client+".json")
+	return httpclient.NewRestClient(5*time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		httpclient.WithCassette(httpclient.CassetteReplay, path))
+}
+
+func TestReceiptDataGenerator(t *testing.T) {
+	for _, provider := range []string{constants.LLM_PROVIDER_GOOGLEAI, constants.LLM_PROVIDER_HUGGINGFACE} {
+		t.Run(provider, func(t *testing.T) {
+			ctx := context.Background()
+
+			promptSvc := promptService.NewPromptService(&config.PromptConf{}, prompts.FS, nil)
+			if err := promptSvc.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			receipts := &memoryReceiptRepository{}
+
+			service := NewOCRService(
+				&config.OCRConf{DefaultTimezone: "Asia/Jakarta", DefaultCurrency: "IDR"},
+				nil,
+				ocrRepo.NewGoogleAIHTTP(&config.GoogleAIAPIConf{
+					URL:   "https://generativelanguage.googleapis.com/v1beta",
+					Model: "gemini-2.0-flash",
+				}, replayClient("googleai")),
+				ocrRepo.NewHuggingFaceHTTP(&config.HuggingFaceAPIConf{
+					URL:   "https://api-inference.huggingface.co",
+					Model: "mistralai/Mistral-7B-Instruct-v0.3",
+				}, replayClient("huggingface")),
+				receipts,
+				stubTenantService{settings: tenantModel.Tenant{LLMProvider: provider}},
+				promptSvc,
+				exampleService.NewExampleService(&config.FewShotConf{}, nil, nil),
+				logo.NewTemplates(),
+			).(*ocr)
+			// no tesseract in tests, the image is read as receiptText
+			service.readText = func(context.Context, []byte) (string, error) {
+				return receiptText, nil
+			}
+
+			result, err := service.ReceiptDataGenerator(ctx, []byte("receipt image"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.TransactionID != "TRX20250112093015" || result.Amount != 150000 {
+				t.Errorf("unexpected receipt %+v", result.ReceiptTransaction)
+			}
+			if result.Normalized.Date == nil || *result.Normalized.Date != "2025-01-12" {
+				t.Errorf("normalized date = %v, want 2025-01-12", result.Normalized.Date)
+			}
+			if result.Normalized.Amount == nil || result.Normalized.Amount.MinorUnits != 15000000 || result.Normalized.Amount.Currency != "IDR" {
+				t.Errorf("normalized amount = %+v, want 15000000 IDR minor units", result.Normalized.Amount)
+			}
+
+			if len(receipts.created) != 1 {
+				t.Fatalf("%d receipts stored, want 1", len(receipts.created))
+			}
+			if stored := receipts.created[0]; stored.LLMProvider != provider || stored.OCRText != receiptText {
+				t.Errorf("stored receipt of %s with text %q", stored.LLMProvider, stored.OCRText)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"rest-app/internal/app/ocr/model"
+	"rest-app/pkg/constants"
+	"rest-app/pkg/metrics"
+	"rest-app/pkg/tracing"
+	"rest-app/pkg/validations"
+
+	promptModel "rest-app/internal/app/prompt/model"
+
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// validateReceipt the rules receipt breaks, they are the binding tags of model.ReceiptTransaction
+func validateReceipt(receipt *model.ReceiptTransaction) []model.Violation {
+	var violations []model.Violation
+	for _, fieldErr := range validations.Struct(receipt) {
+		violations = append(violations, model.Violation{Field: fieldErr.Field(), Message: violationMessage(receipt, fieldErr)})
+	}
+	return violations
+}
+
+// violationMessage says what is wrong with the value, the LLM is sent it to correct the field
+func violationMessage(receipt *model.ReceiptTransaction, fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "gt":
+		return fmt.Sprintf("must be more than %s, got %v", fieldErr.Param(), fieldErr.Value())
+	case "gte":
+		return fmt.Sprintf("must not be negative, got %v", fieldErr.Value())
+	case "ltefield":
+		return fmt.Sprintf("must not exceed the amount %v, got %v", receipt.Amount, fieldErr.Value())
+	case validations.StructValidationCurrency + "|iso4217":
+		return fmt.Sprintf("%q is not a currency", fieldErr.Value())
+	case validations.StructValidationReceiptDate:
+		return fmt.Sprintf("%q is not a date", fieldErr.Value())
+	case validations.StructValidationAccountNumber:
+		if fieldErr.Param() != "" && receipt.BankName != "" {
+			return fmt.Sprintf("%q doesn't match the account numbers of %s", fieldErr.Value(), receipt.BankName)
+		}
+		return fmt.Sprintf("%q is not an account number", fieldErr.Value())
+	default:
+		return fieldErr.Error()
+	}
+}
+
+// repair sends the receipt of extraction back once to the LLM with the rules it breaks. The
+// correction is completed by the layout of the issuer like the first result and replaces the
+// receipt only when it breaks fewer rules, the ones left are the warnings.
+// On error data is returned as is, the first result is still worth returning
+func (o *ocr) repair(ctx context.Context, provider string, extraction *model.Extraction, issuer issuer, data []byte, violations []model.Violation) (_ []byte, err error) {
+	extraction.Warnings = violations
+	if !o.conf.Load().RepairEnabled {
+		return data, nil
+	}
+
+	ctx, span := tracing.Start(ctx, "ocr.repair", trace.WithAttributes(attribute.Int("receipt.violations", len(violations))))
+	defer tracing.End(span, &err)
+
+	promptViolations := make([]promptModel.Violation, 0, len(violations))
+	for _, violation := range violations {
+		promptViolations = append(promptViolations, promptModel.Violation{Field: violation.Field, Message: violation.Message})
+	}
+	prompt, err := o.PromptService.Render(ctx, constants.PROMPT_RECEIPT_REPAIR, provider, promptModel.Data{
+		Text:             extraction.Text,
+		LLMProvider:      provider,
+		Format:           receiptFormat,
+		StructuredOutput: provider == constants.LLM_PROVIDER_GOOGLEAI,
+		Previous:         string(data),
+		Violations:       promptViolations,
+	})
+	if err != nil {
+		return data, err
+	}
+
+	res, err := o.generateJSON(ctx, provider, prompt)
+	if err != nil {
+		return data, err
+	}
+	res = issuer.complete(res)
+
+	var repaired model.ReceiptTransaction
+	if err := json.Unmarshal(res, &repaired); err != nil {
+		return data, fmt.Errorf("LLM provider returned an unexpected result: %w", err)
+	}
+
+	remaining := validateReceipt(&repaired)
+	span.SetAttributes(attribute.Int("receipt.violations_left", len(remaining)))
+	if len(remaining) >= len(violations) {
+		metrics.ExtractionRepairs.WithLabelValues("rejected").Inc()
+		return data, nil
+	}
+
+	if len(remaining) == 0 {
+		metrics.ExtractionRepairs.WithLabelValues("fixed").Inc()
+	} else {
+		metrics.ExtractionRepairs.WithLabelValues("partial").Inc()
+	}
+	extraction.Receipt = &repaired
+	extraction.RepairPromptVersion = prompt.Version
+	extraction.Warnings = remaining
+	return res, nil
+}
@@ -0,0 +1,8 @@
+package model
+
+import "rest-app/pkg/apperror"
+
+var (
+	ErrTemplateNotFound = apperror.New(apperror.Internal, "no prompt template for this document type and provider")
+	ErrNotLoaded        = apperror.New(apperror.Internal, "prompt templates are not loaded")
+)
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// Template a version of the prompt of a document type, Provider empty means any LLM provider.
+// A published version is never edited, a change is a new version
+type Template struct {
+	DocumentType string    `json:"document_type" gorm:"column:document_type"`
+	LLMProvider  string    `json:"llm_provider" gorm:"column:llm_provider"`
+	Version      string    `json:"version" gorm:"column:version"`
+	Body         string    `json:"template" gorm:"column:template"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (Template) TableName() string {
+	return "prompt_templates"
+}
+
+// Data the values a template is executed with
+type Data struct {
+	// Text the OCR text to extract from
+	Text        string
+	LLMProvider string
+	// Format an empty JSON document of the expected result
+	Format string
+	// StructuredOutput the provider is given the response schema, the prompt needn't describe it
+	StructuredOutput bool
+	// Examples texts of similar receipts with their expected JSON, may be empty
+	Examples []Example
+	// Previous the JSON a repair prompt asks to correct
+	Previous string
+	// Violations the rules Previous breaks
+	Violations []Violation
+	// Institution name of the bank or e-wallet detected as the issuer, empty when none was
+	Institution string
+	// Hints values the layout of the institution labels on the receipt, may be empty
+	Hints []Hint
+}
+
+// Example a few-shot example as templates show it
+type Example struct {
+	Text string
+	JSON string
+}
+
+// Violation a rule broken by a field of the JSON to repair
+type Violation struct {
+	Field   string
+	Message string
+}
+
+// Hint a value read from a labelled line of the receipt
+type Hint struct {
+	Field string
+	Value string
+}
+
+// Prompt a rendered template
+type Prompt struct {
+	Version string
+	Text    string
+}
@@ -0,0 +1,10 @@
+package port
+
+import (
+	"context"
+	"rest-app/internal/app/prompt/model"
+)
+
+type IPromptRepository interface {
+	List(ctx context.Context) ([]model.Template, error)
+}
@@ -0,0 +1,15 @@
+package port
+
+import (
+	"context"
+	"rest-app/config"
+	"rest-app/internal/app/prompt/model"
+)
+
+type IPromptService interface {
+	// Load parses the embedded templates and the ones stored in the database, it must succeed before Render is called
+	Load(ctx context.Context) error
+	// Render executes the template version selected for documentType and provider
+	Render(ctx context.Context, documentType, provider string, data model.Data) (*model.Prompt, error)
+	SetConfig(conf config.PromptConf)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"rest-app/config/db"
+	"rest-app/internal/app/prompt/model"
+	"rest-app/internal/app/prompt/port"
+	"rest-app/pkg/transaction"
+)
+
+type promptDB struct {
+	db *db.GormDB
+}
+
+func NewPromptDB(db *db.GormDB) port.IPromptRepository {
+	return &promptDB{
+		db: db,
+	}
+}
+
+func (r *promptDB) List(ctx context.Context) ([]model.Template, error) {
+	var templates []model.Template
+
+	err := transaction.GetTrxContext(ctx, r.db).
+		Order("document_type, llm_provider, version").
+		Find(&templates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/rand/v2"
+	"regexp"
+	"rest-app/config"
+	"rest-app/internal/app/prompt/model"
+	"rest-app/internal/app/prompt/port"
+	"rest-app/pkg/constants"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+)
+
+// templateFile <document_type>.<version>.tmpl for every provider, <document_type>.<version>.<provider>.tmpl for one
+var templateFile = regexp.MustCompile(`^([a-z_]+)\.(v[0-9]+)(?:\.([a-z_]+))?\.tmpl$`)
+
+// llmProviders checked for a template when a version is pinned for a whole document type
+var llmProviders = []string{constants.LLM_PROVIDER_GOOGLEAI, constants.LLM_PROVIDER_HUGGINGFACE}
+
+type templateKey struct {
+	documentType string
+	provider     string
+	version      string
+}
+
+type templates map[templateKey]*template.Template
+
+type promptService struct {
+	conf       atomic.Pointer[config.PromptConf]
+	templates  atomic.Pointer[templates]
+	fsys       fs.FS
+	promptRepo port.IPromptRepository
+}
+
+// NewPromptService fsys holds the built-in templates, promptRepo is optional and may be nil
+func NewPromptService(conf *config.PromptConf, fsys fs.FS, promptRepo port.IPromptRepository) port.IPromptService {
+	s := &promptService{
+		fsys:       fsys,
+		promptRepo: promptRepo,
+	}
+	s.conf.Store(conf)
+	return s
+}
+
+// SetConfig swaps the pinned versions, pins of versions that don't exist are rejected as a whole
+func (s *promptService) SetConfig(conf config.PromptConf) {
+	if loaded := s.templates.Load(); loaded != nil {
+		if err := loaded.checkPinned(conf); err != nil {
+			slog.Error("prompt versions not applied", slog.Any("error", err))
+			return
+		}
+	}
+	s.conf.Store(&conf)
+}
+
+func (s *promptService) Load(ctx context.Context) error {
+	loaded := templates{}
+
+	entries, err := fs.ReadDir(s.fsys, ".")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		match := templateFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		body, err := fs.ReadFile(s.fsys, entry.Name())
+		if err != nil {
+			return err
+		}
+		if err := loaded.add(templateKey{documentType: match[1], version: match[2], provider: match[3]}, string(body)); err != nil {
+			return err
+		}
+	}
+
+	if s.promptRepo != nil {
+		stored, err := s.promptRepo.List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list prompt templates: %w", err)
+		}
+		for _, t := range stored {
+			key := templateKey{documentType: t.DocumentType, provider: t.LLMProvider, version: t.Version}
+			// versions are immutable, a stored copy of a built-in one would make results of the same version incomparable
+			if _, ok := loaded[key]; ok {
+				slog.Warn("stored prompt template ignored, the version is built in", slog.String("prompt", key.String()))
+				continue
+			}
+			if err := loaded.add(key, t.Body); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := loaded.checkPinned(*s.conf.Load()); err != nil {
+		return err
+	}
+	s.templates.Store(&loaded)
+	return nil
+}
+
+func (s *promptService) Render(ctx context.Context, documentType, provider string, data model.Data) (*model.Prompt, error) {
+	loaded := s.templates.Load()
+	if loaded == nil {
+		return nil, model.ErrNotLoaded
+	}
+
+	version := loaded.selectVersion(*s.conf.Load(), documentType, provider)
+	t := loaded.lookup(documentType, provider, version)
+	if t == nil {
+		return nil, model.ErrTemplateNotFound
+	}
+
+	var text strings.Builder
+	if err := t.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render prompt %s: %w", t.Name(), err)
+	}
+
+	return &model.Prompt{Version: version, Text: text.String()}, nil
+}
+
+func (k templateKey) String() string {
+	if k.provider == "" {
+		return k.documentType + "." + k.version
+	}
+	return k.documentType + "." + k.version + "." + k.provider
+}
+
+func (t templates) add(key templateKey, body string) error {
+	if !strings.HasPrefix(key.version, "v") || versionNumber(key.version) < 0 {
+		return fmt.Errorf("prompt %s: version must look like v1", key)
+	}
+	parsed, err := template.New(key.String()).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return fmt.Errorf("prompt %s: %w", key, err)
+	}
+	// a reference to a field Data doesn't have only fails on execution, better at startup than on a request
+	if err := parsed.Execute(io.Discard, model.Data{}); err != nil {
+		return fmt.Errorf("prompt %s: %w", key, err)
+	}
+	t[key] = parsed
+	return nil
+}
+
+// lookup a template of provider wins over the one shared by every provider
+func (t templates) lookup(documentType, provider, version string) *template.Template {
+	if parsed, ok := t[templateKey{documentType: documentType, provider: provider, version: version}]; ok {
+		return parsed
+	}
+	return t[templateKey{documentType: documentType, version: version}]
+}
+
+// selectVersion picks among the versions pinned for the provider, then for the document type,
+// at random so a split is even. Without a pin the latest version applying to the provider is used
+func (t templates) selectVersion(conf config.PromptConf, documentType, provider string) string {
+	versions := conf.Versions[documentType+"."+provider]
+	if len(versions) == 0 {
+		versions = conf.Versions[documentType]
+	}
+	if len(versions) > 0 {
+		return versions[rand.IntN(len(versions))]
+	}
+
+	latest := ""
+	for key := range t {
+		if key.documentType != documentType || (key.provider != "" && key.provider != provider) {
+			continue
+		}
+		if latest == "" || versionNumber(key.version) > versionNumber(latest) {
+			latest = key.version
+		}
+	}
+	return latest
+}
+
+// checkPinned every pinned version must have a template for the providers it applies to
+func (t templates) checkPinned(conf config.PromptConf) error {
+	var errs []error
+	for name, versions := range conf.Versions {
+		documentType, provider, _ := strings.Cut(name, ".")
+		providers := []string{provider}
+		if provider == "" {
+			providers = llmProviders
+		}
+
+		for _, p := range providers {
+			if provider == "" && len(conf.Versions[documentType+"."+p]) > 0 {
+				continue
+			}
+			for _, version := range versions {
+				if t.lookup(documentType, p, version) == nil {
+					errs = append(errs, fmt.Errorf("PROMPT_VERSIONS %s: no %s template %s for %s", name, documentType, version, p))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// versionNumber the number of a vN version, -1 when it is not one
+func versionNumber(version string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
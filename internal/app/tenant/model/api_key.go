@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"rest-app/pkg/tenant"
+)
+
+type APIKey struct {
+	tenant.Owned
+	ID        string     `json:"id" gorm:"column:id;primaryKey"`
+	UserID    string     `json:"user_id" gorm:"column:user_id"`
+	Name      string     `json:"name" gorm:"column:name"`
+	KeyHash   string     `json:"-" gorm:"column:key_hash"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at"`
+	RevokedAt *time.Time `json:"revoked_at" gorm:"column:revoked_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
@@ -0,0 +1,10 @@
+package model
+
+import "rest-app/pkg/apperror"
+
+var (
+	ErrUnknownTenant = apperror.New(apperror.Forbidden, "unknown tenant")
+	ErrInvalidAPIKey = apperror.New(apperror.Unauthorized, "invalid api key")
+	// ErrNoTenant an account not bound to a tenant would read and write outside of any tenant
+	ErrNoTenant = apperror.New(apperror.Forbidden, "the credentials are not bound to a tenant")
+)
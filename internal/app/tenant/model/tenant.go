@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Tenant struct {
+	ID                   string         `json:"id" gorm:"column:id;primaryKey"`
+	Name                 string         `json:"name" gorm:"column:name"`
+	Plan                 string         `json:"plan" gorm:"column:plan"`
+	LLMProvider          string         `json:"llm_provider" gorm:"column:llm_provider"`
+	AllowedDocumentTypes pq.StringArray `json:"allowed_document_types" gorm:"column:allowed_document_types;type:text[]"`
+	CreatedAt            time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt            *time.Time     `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt            *time.Time     `json:"deleted_at" gorm:"column:deleted_at"`
+}
+
+func (Tenant) TableName() string {
+	return "tenants"
+}
+
+// AllowsDocumentType reports whether the tenant may process the given document type.
+// An empty list allows every document type.
+func (t *Tenant) AllowsDocumentType(documentType string) bool {
+	if len(t.AllowedDocumentTypes) == 0 {
+		return true
+	}
+	for _, allowed := range t.AllowedDocumentTypes {
+		if allowed == documentType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+package port
+
+import (
+	"context"
+	"rest-app/internal/app/tenant/model"
+)
+
+type ITenantRepository interface {
+	GetByID(ctx context.Context, id string) (*model.Tenant, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+}
@@ -0,0 +1,13 @@
+package port
+
+import (
+	"context"
+	"rest-app/internal/app/tenant/model"
+)
+
+type ITenantService interface {
+	// GetSettings returns the settings of the tenant bound to ctx, falling back
+	// to the global defaults when the request is not tenant scoped.
+	GetSettings(ctx context.Context) (*model.Tenant, error)
+	AuthenticateAPIKey(ctx context.Context, apiKey string) (*model.APIKey, error)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"rest-app/config/db"
+	"rest-app/internal/app/tenant/model"
+	"rest-app/internal/app/tenant/port"
+	"rest-app/pkg/transaction"
+)
+
+type tenantDB struct {
+	db *db.GormDB
+}
+
+func NewTenantDB(db *db.GormDB) port.ITenantRepository {
+	return &tenantDB{
+		db: db,
+	}
+}
+
+func (r *tenantDB) GetByID(ctx context.Context, id string) (*model.Tenant, error) {
+	var tenant model.Tenant
+
+	err := transaction.GetTrxContext(ctx, r.db).
+		Where("id = ? AND deleted_at IS NULL", id).
+		First(&tenant).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenant, nil
+}
+
+func (r *tenantDB) GetAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var apiKey model.APIKey
+
+	err := transaction.GetTrxContext(ctx, r.db).
+		Where("key_hash = ? AND revoked_at IS NULL", keyHash).
+		First(&apiKey).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rest-app/config"
+	"rest-app/internal/app/tenant/model"
+	"rest-app/internal/app/tenant/port"
+	"rest-app/pkg/encrypt"
+	"rest-app/pkg/tenant"
+
+	"gorm.io/gorm"
+)
+
+type tenantService struct {
+	conf       *config.TenantConf
+	tenantRepo port.ITenantRepository
+}
+
+func NewTenantService(conf *config.TenantConf, tenantRepo port.ITenantRepository) port.ITenantService {
+	return &tenantService{
+		conf:       conf,
+		tenantRepo: tenantRepo,
+	}
+}
+
+func (s *tenantService) defaultSettings() *model.Tenant {
+	return &model.Tenant{
+		Plan:                 s.conf.DefaultPlan,
+		LLMProvider:          s.conf.DefaultLLMProvider,
+		AllowedDocumentTypes: s.conf.DefaultDocumentTypes,
+	}
+}
+
+func (s *tenantService) GetSettings(ctx context.Context) (*model.Tenant, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok || s.tenantRepo == nil {
+		return s.defaultSettings(), nil
+	}
+
+	t, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrUnknownTenant
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if t.LLMProvider == "" {
+		t.LLMProvider = s.conf.DefaultLLMProvider
+	}
+	if t.Plan == "" {
+		t.Plan = s.conf.DefaultPlan
+	}
+
+	return t, nil
+}
+
+func (s *tenantService) AuthenticateAPIKey(ctx context.Context, apiKey string) (*model.APIKey, error) {
+	if s.tenantRepo == nil || apiKey == "" {
+		return nil, model.ErrInvalidAPIKey
+	}
+
+	key, err := s.tenantRepo.GetAPIKeyByHash(ctx, encrypt.HashAPIKey(apiKey))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
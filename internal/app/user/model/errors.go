@@ -0,0 +1,11 @@
+package model
+
+import "rest-app/pkg/apperror"
+
+var (
+	ErrUsernameRequired = apperror.New(apperror.Validation, "username is required")
+	ErrPasswordTooShort = apperror.New(apperror.Validation, "password must be at least 8 characters")
+	ErrInvalidRole      = apperror.New(apperror.Validation, "role must be admin or user")
+	ErrUsernameTaken    = apperror.New(apperror.Validation, "username is already taken")
+	ErrTenantRequired   = apperror.New(apperror.Validation, "tenant is required")
+)
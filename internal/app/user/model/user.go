@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+type User struct {
+	ID        string     `json:"id" gorm:"column:id;primaryKey;default:uuid_generate_v4()"`
+	Username  string     `json:"username" gorm:"column:username"`
+	Password  string     `json:"-" gorm:"column:password"`
+	Role      string     `json:"role" gorm:"column:role"`
+	TenantID  string     `json:"tenant_id" gorm:"column:tenant_id"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt *time.Time `json:"deleted_at" gorm:"column:deleted_at"`
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+// CreateUser Password is the plain password, it is only stored hashed
+type CreateUser struct {
+	Username string
+	Password string
+	Role     string
+	TenantID string
+}
@@ -0,0 +1,11 @@
+package port
+
+import (
+	"context"
+	"rest-app/internal/app/user/model"
+)
+
+type IUserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	ExistsByUsername(ctx context.Context, username string) (bool, error)
+}
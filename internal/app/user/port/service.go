@@ -0,0 +1,10 @@
+package port
+
+import (
+	"context"
+	"rest-app/internal/app/user/model"
+)
+
+type IUserService interface {
+	Create(ctx context.Context, input model.CreateUser) (*model.User, error)
+}
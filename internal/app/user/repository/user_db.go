@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"rest-app/config/db"
+	"rest-app/internal/app/user/model"
+	"rest-app/internal/app/user/port"
+	"rest-app/pkg/transaction"
+)
+
+type userDB struct {
+	db *db.GormDB
+}
+
+func NewUserDB(db *db.GormDB) port.IUserRepository {
+	return &userDB{
+		db: db,
+	}
+}
+
+func (r *userDB) Create(ctx context.Context, user *model.User) error {
+	return transaction.GetTrxContext(ctx, r.db).Create(user).Error
+}
+
+func (r *userDB) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	var count int64
+
+	err := transaction.GetTrxContext(ctx, r.db).
+		Model(&model.User{}).
+		Where("username = ? AND deleted_at IS NULL", username).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
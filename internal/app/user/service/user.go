@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"rest-app/internal/app/user/model"
+	"rest-app/internal/app/user/port"
+	"rest-app/pkg/encrypt"
+	"strings"
+)
+
+// minPasswordLength shorter passwords are refused
+const minPasswordLength = 8
+
+type userService struct {
+	userRepo port.IUserRepository
+}
+
+func NewUserService(userRepo port.IUserRepository) port.IUserService {
+	return &userService{
+		userRepo: userRepo,
+	}
+}
+
+func (s *userService) Create(ctx context.Context, input model.CreateUser) (*model.User, error) {
+	username := strings.TrimSpace(input.Username)
+	if username == "" {
+		return nil, model.ErrUsernameRequired
+	}
+	tenantID := strings.TrimSpace(input.TenantID)
+	if tenantID == "" {
+		return nil, model.ErrTenantRequired
+	}
+	if len(input.Password) < minPasswordLength {
+		return nil, model.ErrPasswordTooShort
+	}
+
+	role := input.Role
+	if role == "" {
+		role = model.RoleUser
+	}
+	if role != model.RoleAdmin && role != model.RoleUser {
+		return nil, model.ErrInvalidRole
+	}
+
+	exists, err := s.userRepo.ExistsByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check username: %w", err)
+	}
+	if exists {
+		return nil, model.ErrUsernameTaken
+	}
+
+	hash, err := encrypt.HashPassword(input.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &model.User{
+		Username: username,
+		Password: hash,
+		Role:     role,
+		TenantID: tenantID,
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
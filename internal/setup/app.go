@@ -1,17 +1,42 @@
 package setup
 
 import (
+	"log"
 	"log/slog"
+	"path/filepath"
 	"rest-app/config"
+	"rest-app/config/db"
+	"rest-app/pkg/bank/logo"
+	"rest-app/pkg/cache"
+	"rest-app/pkg/captcha"
 	"rest-app/pkg/httpclient"
+	"rest-app/pkg/logging"
+	"rest-app/pkg/ratelimit"
+	"rest-app/pkg/tesseract"
+	"rest-app/prompts"
 	"time"
 
-	"github.com/otiai10/gosseract/v2"
+	healthHandler "rest-app/internal/app/health/handler"
+	healthModel "rest-app/internal/app/health/model"
+	healthPort "rest-app/internal/app/health/port"
+	healthService "rest-app/internal/app/health/service"
 
 	ocrHandler "rest-app/internal/app/ocr/handler"
 	ocrPort "rest-app/internal/app/ocr/port"
 	ocrRepo "rest-app/internal/app/ocr/repository"
 	ocrService "rest-app/internal/app/ocr/service"
+
+	examplePort "rest-app/internal/app/example/port"
+	exampleRepo "rest-app/internal/app/example/repository"
+	exampleService "rest-app/internal/app/example/service"
+
+	promptPort "rest-app/internal/app/prompt/port"
+	promptRepo "rest-app/internal/app/prompt/repository"
+	promptService "rest-app/internal/app/prompt/service"
+
+	tenantPort "rest-app/internal/app/tenant/port"
+	tenantRepo "rest-app/internal/app/tenant/repository"
+	tenantService "rest-app/internal/app/tenant/service"
 )
 
 type InternalAppStruct struct {
@@ -20,38 +45,218 @@ type InternalAppStruct struct {
 	Handler      InitHandlerApp
 	Config       config.Config
 	Logger       *slog.Logger
+	DB           *db.DbConfig
+	Cache        cache.ICache
+	RateLimiter  ratelimit.ILimiter
+	OCRQuota     ratelimit.IQuota
+	Captcha      captcha.IVerifier
+	OCRPool      *tesseract.Pool
+	Logos        *logo.Templates
 }
 
 type initRepositoriesApp struct {
 	huggingFaceHttpRepo            ocrPort.IHuggingFaceHTTP
 	googleaiTextGenerationHTTPRepo ocrPort.IGoogleAIHTTP
+	receiptDBRepo                  ocrPort.IReceiptRepository
+	tenantDBRepo                   tenantPort.ITenantRepository
+	promptDBRepo                   promptPort.IPromptRepository
+	exampleDBRepo                  examplePort.IExampleRepository
 }
 
 func initAppRepo(initializeApp *InternalAppStruct) {
-	// initializeApp.Repositories.huggingFaceHttpRepo = ocrRepo.NewHuggingFaceHTTP(
-	// 	&initializeApp.Config.HuggingFaceAPIConf,
-	// 	httpclient.NewRestClient(3*time.Minute,
-	// 		initializeApp.Logger))
+	if initializeApp.Config.HuggingFaceAPIConf.URL != "" {
+		initializeApp.Repositories.huggingFaceHttpRepo = ocrRepo.NewHuggingFaceHTTP(
+			&initializeApp.Config.HuggingFaceAPIConf,
+			httpclient.NewRestClient(3*time.Minute,
+				initializeApp.Logger,
+				httpClientOptions(initializeApp.Config, "huggingface", initializeApp.Config.HuggingFaceAPIConf.HTTPLog)...))
+	}
 
 	initializeApp.Repositories.googleaiTextGenerationHTTPRepo = ocrRepo.NewGoogleAIHTTP(
 		&initializeApp.Config.GoogleAIAPIConf,
 		httpclient.NewRestClient(3*time.Minute,
-			initializeApp.Logger))
+			initializeApp.Logger,
+			httpClientOptions(initializeApp.Config, "googleai", initializeApp.Config.GoogleAIAPIConf.HTTPLog)...))
+
+	if initializeApp.DB != nil {
+		initializeApp.Repositories.receiptDBRepo = ocrRepo.NewReceiptDB(initializeApp.DB.GormDB)
+		initializeApp.Repositories.tenantDBRepo = tenantRepo.NewTenantDB(initializeApp.DB.GormDB)
+		initializeApp.Repositories.promptDBRepo = promptRepo.NewPromptDB(initializeApp.DB.GormDB)
+		initializeApp.Repositories.exampleDBRepo = exampleRepo.NewExampleDB(initializeApp.DB.GormDB)
+	}
+}
+
+// httpClientOptions merges the shared redaction rules with a client's own level and body paths
+// and applies the retry, circuit breaker and cassette settings, name picks the client's cassette file.
+// Cassettes only scrub credentials, the body paths would mask the output a replay has to return
+func httpClientOptions(conf config.Config, name string, clientLog config.HTTPClientLogConf) []httpclient.Option {
+	cassetteMode, err := httpclient.ParseCassetteMode(conf.HTTPClient.CassetteMode)
+	if err != nil {
+		log.Fatalln("invalid HTTP_CASSETTE_MODE:", err)
+	}
+
+	return []httpclient.Option{
+		httpclient.WithLogOptions(httpclient.LogOptions{
+			Level:             logging.ParseLevel(clientLog.Level),
+			RedactHeaders:     conf.HTTPLog.RedactHeaders,
+			RedactQueryParams: conf.HTTPLog.RedactQueryParams,
+			RedactBodyPaths:   clientLog.RedactBodyPaths,
+			MaxBodyBytes:      conf.HTTPLog.MaxBodyBytes,
+		}),
+		httpclient.WithRetryPolicy(httpclient.RetryPolicy{
+			MaxAttempts: conf.HTTPClient.RetryMaxAttempts,
+			BaseDelay:   conf.HTTPClient.RetryBaseDelay,
+			MaxDelay:    conf.HTTPClient.RetryMaxDelay,
+		}),
+		httpclient.WithBreakerPolicy(httpclient.BreakerPolicy{
+			FailureThreshold: conf.HTTPClient.BreakerFailureThreshold,
+			OpenFor:          conf.HTTPClient.BreakerOpenFor,
+		}),
+		httpclient.WithCassette(cassetteMode, filepath.Join(conf.HTTPClient.CassetteDir, name+".json")),
+		httpclient.WithCassetteScrub(httpclient.CassetteScrub{
+			Headers:     conf.HTTPLog.RedactHeaders,
+			QueryParams: conf.HTTPLog.RedactQueryParams,
+			BodyFields:  httpclient.DefaultCassetteScrub().BodyFields,
+		}),
+	}
+}
+
+func initAppRateLimit(initializeApp *InternalAppStruct) {
+	// buckets are per process, config.Validate rejects backends that would pretend otherwise
+	initializeApp.RateLimiter = ratelimit.NewMemoryLimiter()
+
+	if initializeApp.DB != nil {
+		initializeApp.OCRQuota = ratelimit.NewDBQuota(initializeApp.DB.GormDB.DB)
+	} else {
+		initializeApp.OCRQuota = ratelimit.NewCacheQuota(initializeApp.Cache)
+		slog.Warn("no database, OCR quotas are counted per process and reset on restart")
+	}
+
+	if initializeApp.Config.Anonymous.CaptchaVerifyURL != "" {
+		initializeApp.Captcha = captcha.NewSiteVerifier(
+			initializeApp.Config.Anonymous.CaptchaVerifyURL,
+			initializeApp.Config.Anonymous.CaptchaSecret,
+			httpclient.NewRestClient(10*time.Second,
+				initializeApp.Logger,
+				httpClientOptions(initializeApp.Config, "captcha", config.HTTPClientLogConf{})...))
+	} else {
+		initializeApp.Captcha = captcha.NewNoopVerifier()
+	}
 }
 
 type initServicesApp struct {
-	OCRService ocrPort.IOCRService
+	HealthService healthPort.IHealthService
+	OCRService    ocrPort.IOCRService
+	// UnsavedOCRService never persists its results, serves anonymous requests and offline evaluations
+	UnsavedOCRService ocrPort.IOCRService
+	TenantService     tenantPort.ITenantService
+	PromptService     promptPort.IPromptService
+	ExampleService    examplePort.IExampleService
 }
 
 func initAppService(initializeApp *InternalAppStruct) {
-	initializeApp.Services.OCRService = ocrService.NewOCRService(gosseract.NewClient(), initializeApp.Repositories.googleaiTextGenerationHTTPRepo)
+	initializeApp.Services.TenantService = tenantService.NewTenantService(
+		&initializeApp.Config.Tenant,
+		initializeApp.Repositories.tenantDBRepo)
+
+	// stored templates are read by Load, run when the app starts
+	initializeApp.Services.PromptService = promptService.NewPromptService(
+		&initializeApp.Config.Prompt,
+		prompts.FS,
+		initializeApp.Repositories.promptDBRepo)
+
+	initializeApp.Services.ExampleService = exampleService.NewExampleService(
+		&initializeApp.Config.FewShot,
+		initializeApp.Repositories.exampleDBRepo,
+		initializeApp.Repositories.receiptDBRepo)
+
+	initializeApp.Services.OCRService = ocrService.NewOCRService(
+		&initializeApp.Config.OCR,
+		initializeApp.OCRPool,
+		initializeApp.Repositories.googleaiTextGenerationHTTPRepo,
+		initializeApp.Repositories.huggingFaceHttpRepo,
+		initializeApp.Repositories.receiptDBRepo,
+		initializeApp.Services.TenantService,
+		initializeApp.Services.PromptService,
+		initializeApp.Services.ExampleService,
+		initializeApp.Logos)
+
+	initializeApp.Services.UnsavedOCRService = ocrService.NewOCRService(
+		&initializeApp.Config.OCR,
+		initializeApp.OCRPool,
+		initializeApp.Repositories.googleaiTextGenerationHTTPRepo,
+		initializeApp.Repositories.huggingFaceHttpRepo,
+		nil,
+		initializeApp.Services.TenantService,
+		initializeApp.Services.PromptService,
+		initializeApp.Services.ExampleService,
+		initializeApp.Logos)
+
+	initializeApp.Services.HealthService = healthService.NewHealthService(
+		initializeApp.Config.Health.Timeout,
+		healthCheckers(initializeApp)...)
+}
+
+// healthCheckers readiness checks of every configured dependency, LLM providers
+// are external and only degrade the app
+func healthCheckers(initializeApp *InternalAppStruct) []healthModel.Checker {
+	checkers := []healthModel.Checker{
+		{Name: "tesseract", Critical: true, Check: initializeApp.OCRPool.Ping},
+		{Name: "cache", Critical: true, Check: initializeApp.Cache.Ping},
+	}
+
+	if initializeApp.DB != nil {
+		checkers = append(checkers, healthModel.Checker{Name: "db", Critical: true, Check: initializeApp.DB.Pool.Ping})
+	}
+
+	checkers = append(checkers, healthModel.Checker{
+		Name:     "llm_googleai",
+		CacheFor: initializeApp.Config.Health.LLMCheckInterval,
+		Check:    initializeApp.Repositories.googleaiTextGenerationHTTPRepo.Ping,
+	})
+	if initializeApp.Repositories.huggingFaceHttpRepo != nil {
+		checkers = append(checkers, healthModel.Checker{
+			Name:     "llm_huggingface",
+			CacheFor: initializeApp.Config.Health.LLMCheckInterval,
+			Check:    initializeApp.Repositories.huggingFaceHttpRepo.Ping,
+		})
+	}
+
+	return checkers
 }
 
 // HANDLER INIT
 type InitHandlerApp struct {
-	OCRHandler ocrPort.IOCRHandler
+	HealthCheckHandler  healthPort.IHealthCheckHandler
+	OCRHandler          ocrPort.IOCRHandler
+	AnonymousOCRHandler ocrPort.IOCRHandler
 }
 
 func initAppHandler(initializeApp *InternalAppStruct) {
-	initializeApp.Handler.OCRHandler = ocrHandler.New(initializeApp.Services.OCRService)
+	initializeApp.Handler.HealthCheckHandler = healthHandler.New(initializeApp.Services.HealthService)
+	initializeApp.Handler.OCRHandler = ocrHandler.New(initializeApp.Services.OCRService, initializeApp.Config.OCR.MaxUploadBytes)
+
+	// anonymous results are never persisted
+	if initializeApp.Config.Anonymous.Enabled {
+		initializeApp.Handler.AnonymousOCRHandler = ocrHandler.New(initializeApp.Services.UnsavedOCRService, initializeApp.Config.OCR.MaxUploadBytes)
+	}
+}
+
+// initAppConfigReload hands reloaded settings to the components that can apply them live,
+// config.Watch rejects changes to anything else
+func initAppConfigReload(initializeApp *InternalAppStruct) {
+	services := initializeApp.Services
+	repositories := initializeApp.Repositories
+
+	config.Subscribe(func(conf config.Config) {
+		services.OCRService.SetConfig(conf.OCR)
+		services.UnsavedOCRService.SetConfig(conf.OCR)
+		services.PromptService.SetConfig(conf.Prompt)
+		services.ExampleService.SetConfig(conf.FewShot)
+
+		repositories.googleaiTextGenerationHTTPRepo.SetConfig(conf.GoogleAIAPIConf)
+		if repositories.huggingFaceHttpRepo != nil {
+			repositories.huggingFaceHttpRepo.SetConfig(conf.HuggingFaceAPIConf)
+		}
+	})
 }
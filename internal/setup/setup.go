@@ -1,28 +1,73 @@
 package setup
 
 import (
+	"context"
+	"io"
+	"log"
 	"log/slog"
 	"rest-app/config"
+	"rest-app/config/db"
+	"rest-app/pkg/bank/logo"
+	"rest-app/pkg/cache"
+	"rest-app/pkg/lifecycle"
+	"rest-app/pkg/logging"
+	"rest-app/pkg/metrics"
+	"rest-app/pkg/tesseract"
+	"rest-app/pkg/validations"
 )
 
 // BaseURL base url of api
 const BaseURL = "/v1/api"
 
-// CloseDB close connection to db
-var CloseDB func() error
-
 type SetupData struct {
 	ConfigData  config.Config
 	InternalApp InternalAppStruct
 }
 
-func Init() *SetupData {
+// Init wires the app, components holding resources register their stop hook with lc.
+// Logs are written to logOutput
+func Init(lc *lifecycle.Manager, logOutput io.Writer) *SetupData {
 	configData := config.GetConfig()
 
-	// LOGGER init
-	logger := slog.Default()
+	// LOGGER init, the std log package goes through it too
+	logger := logging.New(logOutput, configData.Log.Format, configData.Log.Level)
+	slog.SetDefault(logger)
+
+	// request bodies and extracted receipts share the custom validations
+	validations.InitStructValidation()
+
+	// DB init, optional until every deployment runs with a database
+	var dbConfig *db.DbConfig
+	if configData.DB.DSN != "" {
+		var err error
+		dbConfig, err = db.Init(configData.DB.DSN, configData.DB.DSNPool)
+		if err != nil {
+			log.Fatalln("failed to connect database:", err)
+		}
+		lc.Append(lifecycle.Hook{
+			Name: "db",
+			OnStop: func(ctx context.Context) error {
+				dbConfig.CloseConnection()
+				return nil
+			},
+		})
+	}
+
+	internalAppVar := initInternalApp(logger, configData, dbConfig)
+
+	lc.Append(lifecycle.Hook{Name: "prompts", OnStart: internalAppVar.Services.PromptService.Load})
+
+	// freed after the tesseract drain, no OCR job matches a logo anymore
+	lc.Append(lifecycle.Hook{
+		Name: "bank logos",
+		OnStart: func(ctx context.Context) error {
+			return internalAppVar.Logos.Load(configData.OCR.LogoDir)
+		},
+		OnStop: internalAppVar.Logos.Close,
+	})
 
-	internalAppVar := initInternalApp(logger, configData)
+	// stops before the DB, running OCR jobs finish first, waiting ones are answered ServiceUnavailable
+	lc.Append(lifecycle.Hook{Name: "tesseract", OnStop: internalAppVar.OCRPool.Drain})
 
 	return &SetupData{
 		ConfigData:  configData,
@@ -30,15 +75,24 @@ func Init() *SetupData {
 	}
 }
 
-func initInternalApp(logger *slog.Logger, conf config.Config) InternalAppStruct {
+func initInternalApp(logger *slog.Logger, conf config.Config, dbConfig *db.DbConfig) InternalAppStruct {
 	var internalAppVar InternalAppStruct
 
 	internalAppVar.Logger = logger
 	internalAppVar.Config = conf
+	internalAppVar.DB = dbConfig
+	// process local until a shared cache backend is configured
+	internalAppVar.Cache = cache.NewMemoryCache()
+	internalAppVar.OCRPool = tesseract.NewPool(conf.OCR.PoolSize)
+	metrics.RegisterOCRPool(internalAppVar.OCRPool.Size, internalAppVar.OCRPool.InUse)
+	// templates are read by Load, run when the app starts
+	internalAppVar.Logos = logo.NewTemplates()
 
+	initAppRateLimit(&internalAppVar)
 	initAppRepo(&internalAppVar)
 	initAppService(&internalAppVar)
 	initAppHandler(&internalAppVar)
+	initAppConfigReload(&internalAppVar)
 
 	return internalAppVar
 }
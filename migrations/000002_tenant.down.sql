@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS receipts;
+DROP TABLE IF EXISTS api_keys;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS tenants;
+
+COMMIT;
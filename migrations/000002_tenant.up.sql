@@ -0,0 +1,38 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(50) PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    llm_provider VARCHAR(50),
+    allowed_document_types TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NULL,
+    deleted_at TIMESTAMPTZ NULL
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(50) NULL REFERENCES tenants (id);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users (tenant_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(50) PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    tenant_id VARCHAR(50) NOT NULL REFERENCES tenants (id),
+    user_id VARCHAR(50) NULL,
+    name VARCHAR(100),
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys (tenant_id);
+
+CREATE TABLE IF NOT EXISTS receipts (
+    id VARCHAR(50) PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    tenant_id VARCHAR(50) NULL REFERENCES tenants (id),
+    document_type VARCHAR(50) NOT NULL,
+    llm_provider VARCHAR(50),
+    ocr_text TEXT,
+    data JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_receipts_tenant_id ON receipts (tenant_id);
+
+COMMIT;
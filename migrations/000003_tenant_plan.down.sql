@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS plan;
+
+COMMIT;
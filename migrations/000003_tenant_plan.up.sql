@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS plan VARCHAR(50) NULL;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_receipts_prompt_version;
+ALTER TABLE receipts DROP COLUMN IF EXISTS prompt_version;
+DROP TABLE IF EXISTS prompt_templates;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    document_type VARCHAR(50) NOT NULL,
+    llm_provider VARCHAR(50) NOT NULL DEFAULT '',
+    version VARCHAR(20) NOT NULL,
+    template TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (document_type, llm_provider, version)
+);
+
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(20) NULL;
+CREATE INDEX IF NOT EXISTS idx_receipts_prompt_version ON receipts (prompt_version);
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS extraction_examples (
+    id VARCHAR(50) PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    tenant_id VARCHAR(50) NULL REFERENCES tenants (id),
+    bank_name VARCHAR(100),
+    ocr_text TEXT NOT NULL,
+    data JSONB NOT NULL,
+    receipt_id VARCHAR(50) NULL REFERENCES receipts (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_receipts_warnings;
+ALTER TABLE receipts DROP COLUMN IF EXISTS warnings;
+ALTER TABLE receipts DROP COLUMN IF EXISTS repair_prompt_version;
+
+COMMIT;
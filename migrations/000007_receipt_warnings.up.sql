@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS repair_prompt_version VARCHAR(20) NULL;
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS warnings JSONB NULL;
+-- receipts left breaking validation rules are the ones to review
+CREATE INDEX IF NOT EXISTS idx_receipts_warnings ON receipts ((warnings IS NOT NULL));
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_receipts_transaction_date;
+ALTER TABLE receipts DROP COLUMN IF EXISTS fee_minor;
+ALTER TABLE receipts DROP COLUMN IF EXISTS amount_minor;
+ALTER TABLE receipts DROP COLUMN IF EXISTS currency;
+ALTER TABLE receipts DROP COLUMN IF EXISTS transacted_at;
+ALTER TABLE receipts DROP COLUMN IF EXISTS transaction_date;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS transaction_date DATE NULL;
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS transacted_at TIMESTAMPTZ NULL;
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NULL;
+-- in the minor unit of currency, 15000000 is IDR 150000.00
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS amount_minor BIGINT NULL;
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS fee_minor BIGINT NULL;
+CREATE INDEX IF NOT EXISTS idx_receipts_transaction_date ON receipts (tenant_id, transaction_date);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_receipts_institution;
+ALTER TABLE receipts DROP COLUMN IF EXISTS institution;
+
+COMMIT;
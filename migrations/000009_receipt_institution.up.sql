@@ -0,0 +1,7 @@
+BEGIN;
+
+-- code of the bank or e-wallet detected as the issuer, BCA, GOPAY
+ALTER TABLE receipts ADD COLUMN IF NOT EXISTS institution VARCHAR(16) NULL;
+CREATE INDEX IF NOT EXISTS idx_receipts_institution ON receipts (tenant_id, institution);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE receipts ALTER COLUMN tenant_id DROP NOT NULL;
+
+COMMIT;
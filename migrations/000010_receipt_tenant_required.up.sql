@@ -0,0 +1,14 @@
+BEGIN;
+
+-- receipts stored without a tenant are readable by no tenant and have to be assigned (or deleted)
+-- by hand before this migration can run
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM receipts WHERE tenant_id IS NULL) THEN
+        RAISE EXCEPTION 'receipts without tenant_id exist, assign them to a tenant first';
+    END IF;
+END $$;
+
+ALTER TABLE receipts ALTER COLUMN tenant_id SET NOT NULL;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS ocr_quota_usage;
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- monthly OCR usage by quota key (tenant, user, api key or anonymous client), period is the first day of the month in UTC
+CREATE TABLE IF NOT EXISTS ocr_quota_usage (
+    key VARCHAR(200) NOT NULL,
+    period DATE NOT NULL,
+    used INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (key, period)
+);
+
+COMMIT;
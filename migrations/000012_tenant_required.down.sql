@@ -0,0 +1,11 @@
+BEGIN;
+
+COMMENT ON TABLE prompt_templates IS NULL;
+
+DROP INDEX IF EXISTS idx_ocr_quota_usage_tenant_period;
+ALTER TABLE ocr_quota_usage DROP CONSTRAINT IF EXISTS ocr_quota_usage_tenant_key;
+ALTER TABLE ocr_quota_usage DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE users ALTER COLUMN tenant_id DROP NOT NULL;
+
+COMMIT;
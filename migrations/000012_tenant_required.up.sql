@@ -0,0 +1,28 @@
+BEGIN;
+
+-- users created before tenants existed have to be assigned to one by hand before this migration can run
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE tenant_id IS NULL) THEN
+        RAISE EXCEPTION 'users without tenant_id exist, assign them to a tenant first';
+    END IF;
+END $$;
+
+ALTER TABLE users ALTER COLUMN tenant_id SET NOT NULL;
+
+-- tenant quotas are stored by tenant_id, rows without one are the anonymous clients counted by ip
+ALTER TABLE ocr_quota_usage ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(50) NULL REFERENCES tenants (id);
+DELETE FROM ocr_quota_usage
+WHERE key LIKE 'tenant:%' AND NOT EXISTS (SELECT 1 FROM tenants WHERE 'tenant:' || tenants.id = ocr_quota_usage.key);
+UPDATE ocr_quota_usage SET tenant_id = substring(key FROM 8) WHERE key LIKE 'tenant:%';
+ALTER TABLE ocr_quota_usage ADD CONSTRAINT ocr_quota_usage_tenant_key
+    CHECK ((tenant_id IS NULL AND key NOT LIKE 'tenant:%') OR key = 'tenant:' || tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ocr_quota_usage_tenant_period ON ocr_quota_usage (tenant_id, period)
+    WHERE tenant_id IS NOT NULL;
+COMMENT ON COLUMN ocr_quota_usage.tenant_id IS 'tenant the quota is counted for, NULL for anonymous clients';
+
+-- prompt templates are deliberately global: they are picked by document type, provider and version,
+-- tenants only choose the provider and document types, not the prompt
+COMMENT ON TABLE prompt_templates IS 'global prompt templates shared by every tenant, deliberately not tenant owned';
+
+COMMIT;
@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS the SQL migrations, NNNNNN_name.up.sql and NNNNNN_name.down.sql as golang-migrate names them
+//
+//go:embed *.sql
+var FS embed.FS
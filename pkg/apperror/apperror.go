@@ -0,0 +1,77 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind classifies an error for API clients, Type is stable and safe to branch on
+type Kind struct {
+	Type   string
+	Status int
+}
+
+var (
+	Validation       = Kind{Type: "ValidationFailed", Status: http.StatusBadRequest}
+	Unauthorized     = Kind{Type: "Unauthorized", Status: http.StatusUnauthorized}
+	Forbidden        = Kind{Type: "Forbidden", Status: http.StatusForbidden}
+	NotFound         = Kind{Type: "NotFound", Status: http.StatusNotFound}
+	PayloadTooLarge  = Kind{Type: "PayloadTooLarge", Status: http.StatusRequestEntityTooLarge}
+	UnsupportedMedia = Kind{Type: "UnsupportedMediaType", Status: http.StatusUnsupportedMediaType}
+	OCRLowQuality    = Kind{Type: "OCRLowQuality", Status: http.StatusUnprocessableEntity}
+	RateLimited      = Kind{Type: "TooManyRequests", Status: http.StatusTooManyRequests}
+	QuotaExceeded    = Kind{Type: "QuotaExceeded", Status: http.StatusTooManyRequests}
+	Internal         = Kind{Type: "InternalServerError", Status: http.StatusInternalServerError}
+	UpstreamProvider = Kind{Type: "UpstreamProviderFailed", Status: http.StatusBadGateway}
+	Unavailable      = Kind{Type: "ServiceUnavailable", Status: http.StatusServiceUnavailable}
+)
+
+// Kinds lists every kind, for documentation
+func Kinds() []Kind {
+	return []Kind{Validation, Unauthorized, Forbidden, NotFound, PayloadTooLarge, UnsupportedMedia, OCRLowQuality, RateLimited, QuotaExceeded, Internal, UpstreamProvider, Unavailable}
+}
+
+// Error is an error of a known kind, Message is returned to clients while the wrapped Err is only logged
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+// New returns an error of kind, sentinels declared with it can be matched with errors.Is
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap classifies err as kind, message replaces err's text in responses
+func Wrap(kind Kind, err error, message string) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// As returns the outermost *Error in err's chain
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// KindOf returns the kind of err, Internal when it was never classified
+func KindOf(err error) Kind {
+	if appErr, ok := As(err); ok {
+		return appErr.Kind
+	}
+	return Internal
+}
@@ -0,0 +1,77 @@
+package bank
+
+import (
+	"regexp"
+	"strings"
+)
+
+// How an institution was detected
+const (
+	ByText = "text"
+	ByLogo = "logo"
+)
+
+const (
+	// headerLines lines at the top of a receipt where the issuer prints its name
+	headerLines = 6
+	// minTextScore a single alias in the body is too weak, it may be the receiver's bank
+	minTextScore = 2
+)
+
+// Detection an institution recognized as the issuer of a receipt, Score is the sum of the weights
+// of the text matches or the correlation, from 0 to 1, of the logo match
+type Detection struct {
+	Institution *Institution
+	By          string
+	Score       float64
+}
+
+var (
+	// nonWordCharacters everything but what aliases and markers are made of
+	nonWordCharacters = regexp.MustCompile(`[^a-z0-9'.\-]+`)
+	// receiverLine a line naming where the money goes, "Transfer ke BCA" in the header doesn't make BCA the issuer
+	receiverLine = regexp.MustCompile(`\b(ke|tujuan|penerima|to)\b`)
+)
+
+// DetectText the institution issuing the receipt of text. An alias counts 1, 2 in the header unless
+// the line names the receiver, a marker (an app or product name only the issuer prints) or the
+// SWIFT code 3. The receiver's bank of a transfer is usually named once, which isn't enough
+func DetectText(text string) (Detection, bool) {
+	lines := strings.Split(text, "\n")
+	padded := make([]string, len(lines))
+	for i, line := range lines {
+		// a period ending a word isn't part of it, "BCA." is BCA
+		padded[i] = strings.ReplaceAll(" "+nonWordCharacters.ReplaceAllString(strings.ToLower(line), " ")+" ", ". ", " ")
+	}
+
+	var best Detection
+	for _, institution := range registry {
+		score := 0.0
+		for i, line := range padded {
+			switch {
+			case containsAny(line, institution.Markers):
+				score += 3
+			case institution.SWIFT != "" && strings.Contains(strings.ToUpper(lines[i]), institution.SWIFT):
+				score += 3
+			case containsAny(line, institution.Aliases) && i < headerLines && !receiverLine.MatchString(line):
+				score += 2
+			case containsAny(line, institution.Aliases):
+				score++
+			}
+		}
+		if score > best.Score {
+			best = Detection{Institution: institution, By: ByText, Score: score}
+		}
+	}
+	return best, best.Score >= minTextScore
+}
+
+// containsAny a phrase of phrases as whole words of line, which is padded with spaces
+func containsAny(line string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(line, " "+phrase+" ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+// Package logo detects the issuer of a receipt by its logo with OpenCV template matching, apart
+// from pkg/bank which stays pure Go
+package logo
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"rest-app/pkg/bank"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// logoSearchWidth receipts are scaled to this width before their header is searched, logo
+	// templates are cut from receipts of this width
+	logoSearchWidth = 600
+	// logoHeaderShare top share of the receipt searched for a logo
+	logoHeaderShare = 3
+)
+
+// logoScales sizes templates are tried at, screenshots and photos don't frame receipts alike
+var logoScales = []float64{0.6, 0.8, 1, 1.25, 1.5}
+
+// logoTemplate a grayscale logo at one scale
+type logoTemplate struct {
+	institution *bank.Institution
+	mat         gocv.Mat
+}
+
+// Templates the logo templates of institutions, read from a directory by Load. Match is safe for
+// concurrent use, templates are only read
+type Templates struct {
+	templates atomic.Pointer[[]logoTemplate]
+}
+
+func NewTemplates() *Templates {
+	return &Templates{}
+}
+
+// Load reads <dir>/<code>.png and <dir>/<code>_<variant>.png (or any format OpenCV reads) for
+// the codes of the registry, case insensitive. An empty dir loads none and logo detection is off
+func (l *Templates) Load(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read logo templates: %w", err)
+	}
+
+	var templates []logoTemplate
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		code, _, _ := strings.Cut(name, "_")
+		institution, ok := bank.ByCode(code)
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		logo := gocv.IMRead(filepath.Join(dir, entry.Name()), gocv.IMReadGrayScale)
+		if logo.Empty() {
+			closeTemplates(templates)
+			return fmt.Errorf("logo template %s could not be decoded", entry.Name())
+		}
+		for _, scale := range logoScales {
+			scaled := gocv.NewMat()
+			gocv.Resize(logo, &scaled, image.Pt(int(float64(logo.Cols())*scale), int(float64(logo.Rows())*scale)), 0, 0, gocv.InterpolationArea)
+			templates = append(templates, logoTemplate{institution: institution, mat: scaled})
+		}
+		logo.Close()
+	}
+
+	if previous := l.templates.Swap(&templates); previous != nil {
+		closeTemplates(*previous)
+	}
+	return nil
+}
+
+// Loaded whether any template was loaded
+func (l *Templates) Loaded() bool {
+	templates := l.templates.Load()
+	return templates != nil && len(*templates) > 0
+}
+
+// Match the institution whose logo correlates best with the header of gray, a grayscale receipt,
+// ok when the correlation reaches minScore
+func (l *Templates) Match(gray gocv.Mat, minScore float64) (bank.Detection, bool) {
+	templates := l.templates.Load()
+	if templates == nil || gray.Empty() {
+		return bank.Detection{}, false
+	}
+
+	resized := gocv.NewMat()
+	defer resized.Close()
+	height := gray.Rows() * logoSearchWidth / gray.Cols()
+	gocv.Resize(gray, &resized, image.Pt(logoSearchWidth, height), 0, 0, gocv.InterpolationArea)
+	header := resized.Region(image.Rect(0, 0, logoSearchWidth, max(height/logoHeaderShare, 1)))
+	defer header.Close()
+
+	result := gocv.NewMat()
+	defer result.Close()
+	mask := gocv.NewMat()
+	defer mask.Close()
+
+	var best bank.Detection
+	for _, t := range *templates {
+		if t.mat.Cols() > header.Cols() || t.mat.Rows() > header.Rows() {
+			continue
+		}
+		if err := gocv.MatchTemplate(header, t.mat, &result, gocv.TmCcoeffNormed, mask); err != nil {
+			continue
+		}
+		if _, score, _, _ := gocv.MinMaxLoc(result); float64(score) > best.Score {
+			best = bank.Detection{Institution: t.institution, By: bank.ByLogo, Score: float64(score)}
+		}
+	}
+	return best, best.Institution != nil && best.Score >= minScore
+}
+
+// Close frees the templates, Match finds nothing afterwards
+func (l *Templates) Close(ctx context.Context) error {
+	if previous := l.templates.Swap(nil); previous != nil {
+		closeTemplates(*previous)
+	}
+	return nil
+}
+
+func closeTemplates(templates []logoTemplate) {
+	for _, t := range templates {
+		t.mat.Close()
+	}
+}
@@ -0,0 +1,166 @@
+package bank
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"rest-app/pkg/normalize"
+)
+
+// Fields values read from a receipt by the layout of its institution, by json field of the
+// receipt. amount and fee are decimal literals
+type Fields map[string]string
+
+// layout labels an institution prints before the values of its receipts, by field. They add to
+// genericLabels, a label is matched at the start of a line and its value follows it on the same
+// line or on the next one
+type layout struct {
+	labels map[string][]string
+}
+
+// genericLabels printed by most Indonesian banks and e-wallets
+var genericLabels = map[string][]string{
+	"amount":           {"nominal", "jumlah", "jumlah transfer", "nominal transfer", "amount"},
+	"fee":              {"biaya", "biaya admin", "biaya transaksi", "biaya layanan", "admin fee", "fee"},
+	"date":             {"tanggal", "tanggal transaksi", "tgl", "date", "waktu transaksi"},
+	"time":             {"jam", "pukul", "waktu", "time"},
+	"reference":        {"no. ref", "no ref", "no. referensi", "no referensi", "nomor referensi", "referensi", "reference", "ref"},
+	"transaction_id":   {"id transaksi", "no. transaksi", "nomor transaksi", "transaction id"},
+	"transaction_type": {"jenis transaksi", "tipe transaksi", "transaction type"},
+	"receiver_name":    {"nama penerima", "penerima", "kepada", "recipient"},
+	"receiver_account": {"rekening tujuan", "no. rekening tujuan", "nomor rekening tujuan", "ke rekening"},
+	"sender_name":      {"nama pengirim", "pengirim", "dari", "sender"},
+	"sender_account":   {"rekening sumber", "rekening asal", "dari rekening", "sumber dana"},
+	"status":           {"status"},
+	"description":      {"berita", "keterangan", "catatan", "deskripsi", "pesan", "description"},
+}
+
+var (
+	bcaLayout = layout{labels: map[string][]string{
+		"receiver_account": {"ke", "rek. tujuan"},
+	}}
+	mandiriLayout = layout{labels: map[string][]string{
+		"receiver_account": {"rekening penerima"},
+	}}
+	bniLayout = layout{labels: map[string][]string{
+		"sender_account":   {"rekening debet", "rekening debit"},
+		"receiver_account": {"rekening kredit"},
+	}}
+	briLayout = layout{labels: map[string][]string{
+		"receiver_name": {"tujuan"},
+	}}
+	bsiLayout = layout{labels: map[string][]string{
+		"receiver_account": {"no. rekening", "nomor rekening"},
+	}}
+	gopayLayout = layout{labels: map[string][]string{
+		"amount":         {"total", "total bayar", "total pembayaran"},
+		"transaction_id": {"order id"},
+		"receiver_name":  {"bayar ke", "transfer ke"},
+	}}
+	ovoLayout = layout{labels: map[string][]string{
+		"amount":           {"total", "total transfer"},
+		"receiver_account": {"nomor tujuan", "no. tujuan", "nomor ponsel"},
+	}}
+	danaLayout = layout{labels: map[string][]string{
+		"amount":           {"total", "total bayar"},
+		"receiver_account": {"nomor hp", "no. hp", "nomor ponsel"},
+	}}
+	shopeePayLayout = layout{labels: map[string][]string{
+		"amount":         {"total", "total pembayaran"},
+		"fee":            {"biaya penanganan"},
+		"transaction_id": {"no. pesanan", "id pesanan"},
+	}}
+	linkAjaLayout = layout{labels: map[string][]string{
+		"amount":           {"total", "total bayar"},
+		"receiver_account": {"nomor tujuan", "no. tujuan"},
+	}}
+)
+
+// labelled a label with the field it introduces
+type labelled struct {
+	label string
+	field string
+}
+
+// account digits, masked or not, with the separators printed between them
+var account = regexp.MustCompile(`\+?[0-9*xX•][0-9*xX• .\-]{4,}[0-9*xX•]`)
+
+// Parse reads the fields the layout of the institution labels in text. A field is left out when
+// its label isn't printed or its value doesn't parse
+func (i *Institution) Parse(text string) Fields {
+	labels := i.layout.merged()
+	lines := strings.Split(text, "\n")
+	fields := Fields{}
+
+	for n, line := range lines {
+		field, value, ok := matchLabel(labels, strings.TrimSpace(line))
+		if !ok || fields[field] != "" {
+			continue
+		}
+		// the value is on the next line when the label stands alone
+		if value == "" && n+1 < len(lines) {
+			if _, _, isLabel := matchLabel(labels, strings.TrimSpace(lines[n+1])); !isLabel {
+				value = strings.TrimSpace(lines[n+1])
+			}
+		}
+		if value, ok := parseValue(field, value); ok {
+			fields[field] = value
+		}
+	}
+	return fields
+}
+
+// merged the labels of the layout with the generic ones, longest first so "nama penerima" wins over "penerima"
+func (l layout) merged() []labelled {
+	var labels []labelled
+	for _, source := range []map[string][]string{genericLabels, l.labels} {
+		for field, names := range source {
+			for _, name := range names {
+				labels = append(labels, labelled{label: name, field: field})
+			}
+		}
+	}
+	slices.SortStableFunc(labels, func(a, b labelled) int {
+		if len(a.label) != len(b.label) {
+			return len(b.label) - len(a.label)
+		}
+		return strings.Compare(a.label, b.label)
+	})
+	return labels
+}
+
+// matchLabel the field of the longest label line starts with, value is what follows it. A label
+// must end a word: "ke" isn't the start of "keterangan"
+func matchLabel(labels []labelled, line string) (field, value string, ok bool) {
+	lower := strings.ToLower(line)
+	for _, l := range labels {
+		if !strings.HasPrefix(lower, l.label) {
+			continue
+		}
+		rest := line[len(l.label):]
+		if r, _ := utf8.DecodeRuneInString(rest); rest != "" && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			continue
+		}
+		return l.field, strings.TrimSpace(strings.TrimLeft(rest, " \t:=-")), true
+	}
+	return "", "", false
+}
+
+// parseValue amounts become decimal literals and accounts lose what surrounds them, names are kept as printed
+func parseValue(field, value string) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+	switch field {
+	case "amount", "fee":
+		return normalize.Decimal(value)
+	case "sender_account", "receiver_account":
+		found := account.FindString(value)
+		return strings.TrimSpace(found), found != ""
+	default:
+		return value, true
+	}
+}
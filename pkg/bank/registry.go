@@ -0,0 +1,201 @@
+package bank
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kind of institution
+const (
+	KindBank    = "bank"
+	KindEWallet = "ewallet"
+)
+
+// Institution a bank or e-wallet receipts are issued by
+type Institution struct {
+	// Code short name, also the name of its logo templates
+	Code string `json:"code"`
+	// Name legal name, the bank_name of its receipts
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// SWIFT BIC of a bank, empty for an e-wallet
+	SWIFT string `json:"swift,omitempty"`
+	// BICode three digit Bank Indonesia clearing code of a bank, empty for an e-wallet
+	BICode string `json:"bi_code,omitempty"`
+	// Aliases lower cased names receipts and LLMs call it by, matched as whole words
+	Aliases []string `json:"-"`
+	// Markers lower cased phrases only receipts of the institution print, app and product names
+	Markers []string `json:"-"`
+	// AccountLengths digits of its account numbers, e-wallets use phone numbers
+	AccountLengths []int `json:"-"`
+
+	layout layout
+}
+
+// phoneLengths digits of Indonesian mobile numbers, 08xx or 628xx
+var phoneLengths = []int{10, 11, 12, 13, 14}
+
+// registry the institutions receipts are recognized from, the first ones win a tie
+var registry = []*Institution{
+	{
+		Code: "BCA", Name: "PT Bank Central Asia Tbk", Kind: KindBank, SWIFT: "CENAIDJA", BICode: "014",
+		Aliases:        []string{"bca", "bank central asia"},
+		Markers:        []string{"m-transfer", "m-bca", "mybca", "klikbca", "bca mobile"},
+		AccountLengths: []int{10},
+		layout:         bcaLayout,
+	},
+	{
+		Code: "MANDIRI", Name: "PT Bank Mandiri (Persero) Tbk", Kind: KindBank, SWIFT: "BMRIIDJA", BICode: "008",
+		Aliases:        []string{"bank mandiri", "mandiri"},
+		Markers:        []string{"livin", "livin'", "livin by mandiri", "mandiri online"},
+		AccountLengths: []int{13},
+		layout:         mandiriLayout,
+	},
+	{
+		Code: "BNI", Name: "PT Bank Negara Indonesia (Persero) Tbk", Kind: KindBank, SWIFT: "BNINIDJA", BICode: "009",
+		Aliases:        []string{"bni", "bank negara indonesia"},
+		Markers:        []string{"bni mobile banking", "wondr", "wondr by bni"},
+		AccountLengths: []int{10},
+		layout:         bniLayout,
+	},
+	{
+		Code: "BRI", Name: "PT Bank Rakyat Indonesia (Persero) Tbk", Kind: KindBank, SWIFT: "BRINIDJA", BICode: "002",
+		Aliases:        []string{"bri", "bank rakyat indonesia"},
+		Markers:        []string{"brimo"},
+		AccountLengths: []int{15},
+		layout:         briLayout,
+	},
+	{
+		Code: "BSI", Name: "PT Bank Syariah Indonesia Tbk", Kind: KindBank, SWIFT: "BSMDIDJA", BICode: "451",
+		Aliases:        []string{"bsi", "bank syariah indonesia"},
+		Markers:        []string{"bsi mobile", "byond", "byond by bsi"},
+		AccountLengths: []int{10},
+		layout:         bsiLayout,
+	},
+	{
+		Code: "BTN", Name: "PT Bank Tabungan Negara (Persero) Tbk", Kind: KindBank, SWIFT: "BTANIDJA", BICode: "200",
+		Aliases:        []string{"btn", "bank tabungan negara"},
+		Markers:        []string{"btn mobile"},
+		AccountLengths: []int{16},
+	},
+	{
+		Code: "CIMB", Name: "PT Bank CIMB Niaga Tbk", Kind: KindBank, SWIFT: "BNIAIDJA", BICode: "022",
+		Aliases:        []string{"cimb niaga", "cimb"},
+		Markers:        []string{"octo mobile", "octo clicks"},
+		AccountLengths: []int{13, 14},
+	},
+	{
+		Code: "PERMATA", Name: "PT Bank Permata Tbk", Kind: KindBank, SWIFT: "BBBAIDJA", BICode: "013",
+		Aliases:        []string{"permata", "bank permata", "permatabank"},
+		Markers:        []string{"permatamobile x"},
+		AccountLengths: []int{10},
+	},
+	{
+		Code: "DANAMON", Name: "PT Bank Danamon Indonesia Tbk", Kind: KindBank, SWIFT: "BDINIDJA", BICode: "011",
+		Aliases:        []string{"danamon", "bank danamon"},
+		Markers:        []string{"d-bank pro"},
+		AccountLengths: []int{10},
+	},
+	{
+		Code: "GOPAY", Name: "GoPay", Kind: KindEWallet,
+		Aliases:        []string{"gopay", "go-pay"},
+		Markers:        []string{"gojek", "gopay coins"},
+		AccountLengths: phoneLengths,
+		layout:         gopayLayout,
+	},
+	{
+		Code: "OVO", Name: "OVO", Kind: KindEWallet,
+		Aliases:        []string{"ovo"},
+		Markers:        []string{"ovo cash", "ovo points", "ovo premier"},
+		AccountLengths: phoneLengths,
+		layout:         ovoLayout,
+	},
+	{
+		// "dana" alone also means funds, "sumber dana" is on every bank receipt
+		Code: "DANA", Name: "DANA", Kind: KindEWallet,
+		Aliases:        []string{"dana indonesia", "dana.id", "saldo dana", "akun dana"},
+		Markers:        []string{"pt espay debit indonesia koe", "dana protection"},
+		AccountLengths: phoneLengths,
+		layout:         danaLayout,
+	},
+	{
+		Code: "SHOPEEPAY", Name: "ShopeePay", Kind: KindEWallet,
+		Aliases:        []string{"shopeepay", "shopee pay"},
+		Markers:        []string{"shopee"},
+		AccountLengths: phoneLengths,
+		layout:         shopeePayLayout,
+	},
+	{
+		Code: "LINKAJA", Name: "LinkAja", Kind: KindEWallet,
+		Aliases:        []string{"linkaja", "link aja"},
+		Markers:        []string{"linkaja syariah"},
+		AccountLengths: phoneLengths,
+		layout:         linkAjaLayout,
+	},
+}
+
+// genericWords words of institution names that don't tell them apart
+var genericWords = regexp.MustCompile(`\(?\b(pt|tbk|persero)\b\)?`)
+
+// All the registered institutions
+func All() []*Institution {
+	return registry
+}
+
+// ByCode the institution of a Code, case insensitive
+func ByCode(code string) (*Institution, bool) {
+	for _, institution := range registry {
+		if strings.EqualFold(institution.Code, code) {
+			return institution, true
+		}
+	}
+	return nil, false
+}
+
+// Lookup the institution a name refers to: its code, legal name, an alias or a marker as whole
+// words of name, e.g. "PT Bank Central Asia Tbk", "BCA", "m-BCA". The longest match wins
+func Lookup(name string) (*Institution, bool) {
+	s := " " + normalizeName(name) + " "
+	var (
+		found   *Institution
+		longest int
+	)
+	for _, institution := range registry {
+		candidates := append([]string{strings.ToLower(institution.Code), normalizeName(institution.Name)}, institution.Aliases...)
+		for _, candidate := range append(candidates, institution.Markers...) {
+			if len(candidate) > longest && strings.Contains(s, " "+candidate+" ") {
+				found, longest = institution, len(candidate)
+			}
+		}
+	}
+	return found, found != nil
+}
+
+func normalizeName(name string) string {
+	name = genericWords.ReplaceAllString(strings.ToLower(name), " ")
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// accountCharacters separators printed between groups of digits and the + of phone numbers
+var accountCharacters = strings.NewReplacer(" ", "", "-", "", ".", "")
+
+// MatchesAccount reports whether account has the length of the institution's account numbers,
+// masked digits (*, x, •) may stand for several. An e-wallet phone number may start with +62 or 62
+func (i *Institution) MatchesAccount(account string) bool {
+	account = accountCharacters.Replace(strings.TrimSpace(account))
+	if i.Kind == KindEWallet {
+		account = strings.TrimPrefix(account, "+")
+		if strings.HasPrefix(account, "62") {
+			account = "0" + account[2:]
+		}
+	}
+
+	masked := strings.ContainsAny(account, "*xX•")
+	length := len([]rune(account))
+	for _, l := range i.AccountLengths {
+		if length == l || (masked && length <= l) {
+			return true
+		}
+	}
+	return false
+}
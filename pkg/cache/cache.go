@@ -2,11 +2,17 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrCacheMiss returned by Get when the key doesn't exist or is expired
+var ErrCacheMiss = errors.New("cache miss")
+
 type ICache interface {
 	Remember(ctx context.Context, key string, ttl time.Duration, retrieveValueFunc func() (interface{}, error)) ([]byte, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
 	Forget(ctx context.Context, key ...string)
 	SetTags(ctx context.Context, key string, tags ...string)
 	ForgetTags(ctx context.Context, tags ...string)
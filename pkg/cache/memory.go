@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (i memoryItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && now.After(i.expiresAt)
+}
+
+// memoryCache is a process local ICache, values are lost on restart and not shared between instances
+type memoryCache struct {
+	mu          sync.RWMutex
+	items       map[string]memoryItem
+	tags        map[string]map[string]struct{}
+	lastEvicted time.Time
+}
+
+// evictInterval how often Set sweeps expired items
+const evictInterval = time.Minute
+
+func NewMemoryCache() ICache {
+	return &memoryCache{
+		items: map[string]memoryItem{},
+		tags:  map[string]map[string]struct{}{},
+	}
+}
+
+func (m *memoryCache) Remember(ctx context.Context, key string, ttl time.Duration, retrieveValueFunc func() (interface{}, error)) ([]byte, error) {
+	if value, err := m.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	v, err := retrieveValueFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := v.([]byte)
+	if !ok {
+		value, err = json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, m.Set(ctx, key, value, ttl)
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.RLock()
+	item, ok := m.items[key]
+	m.mu.RUnlock()
+
+	if !ok || item.expired(time.Now()) {
+		return nil, ErrCacheMiss
+	}
+
+	return item.value, nil
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	item := memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items[key] = item
+	m.evictExpired()
+
+	return nil
+}
+
+// evictExpired drops expired items at most once per evictInterval, caller must hold the write lock
+func (m *memoryCache) evictExpired() {
+	now := time.Now()
+	if now.Sub(m.lastEvicted) < evictInterval {
+		return
+	}
+	m.lastEvicted = now
+
+	for key, item := range m.items {
+		if item.expired(now) {
+			delete(m.items, key)
+		}
+	}
+}
+
+func (m *memoryCache) Forget(ctx context.Context, key ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range key {
+		delete(m.items, k)
+	}
+}
+
+func (m *memoryCache) SetTags(ctx context.Context, key string, tags ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		if _, ok := m.tags[tag]; !ok {
+			m.tags[tag] = map[string]struct{}{}
+		}
+		m.tags[tag][key] = struct{}{}
+	}
+}
+
+func (m *memoryCache) ForgetTags(ctx context.Context, tags ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			delete(m.items, key)
+		}
+		delete(m.tags, tag)
+	}
+}
+
+func (m *memoryCache) Ping(ctx context.Context) error {
+	return nil
+}
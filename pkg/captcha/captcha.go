@@ -0,0 +1,90 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"rest-app/pkg/apperror"
+	"rest-app/pkg/httpclient"
+)
+
+var ErrInvalidToken = apperror.New(apperror.Forbidden, "invalid captcha token")
+
+// IVerifier verifies a captcha token solved by the client
+type IVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes,omitempty"`
+}
+
+type siteVerifier struct {
+	url        string
+	secret     string
+	httpClient *httpclient.RestClient
+}
+
+// NewSiteVerifier verifies tokens against a reCAPTCHA, hCaptcha or Turnstile compatible siteverify endpoint
+func NewSiteVerifier(verifyURL, secret string, httpClient *httpclient.RestClient) IVerifier {
+	return &siteVerifier{
+		url:        verifyURL,
+		secret:     secret,
+		httpClient: httpClient,
+	}
+}
+
+func (v *siteVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrInvalidToken
+	}
+
+	form := url.Values{}
+	form.Set("secret", v.secret)
+	form.Set("response", token)
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded",
+	}
+
+	resp, err := v.httpClient.Post(ctx, v.url, form.Encode(), headers)
+	if err != nil {
+		return apperror.Wrap(apperror.UpstreamProvider, err, "captcha verification failed")
+	}
+
+	if resp.StatusCode() >= 400 {
+		return apperror.Wrap(apperror.UpstreamProvider, fmt.Errorf("status %d", resp.StatusCode()), "captcha verification failed")
+	}
+
+	var verifyResp siteVerifyResponse
+	if err := json.Unmarshal(resp.Body(), &verifyResp); err != nil {
+		return apperror.Wrap(apperror.UpstreamProvider, err, "captcha verification failed")
+	}
+
+	if !verifyResp.Success && len(verifyResp.ErrorCodes) == 0 {
+		return ErrInvalidToken
+	}
+	if !verifyResp.Success {
+		return fmt.Errorf("%w: %s", ErrInvalidToken, strings.Join(verifyResp.ErrorCodes, ","))
+	}
+
+	return nil
+}
+
+type noopVerifier struct{}
+
+// NewNoopVerifier accepts every request, used when no captcha provider is configured
+func NewNoopVerifier() IVerifier {
+	return noopVerifier{}
+}
+
+func (noopVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	return nil
+}
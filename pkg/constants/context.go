@@ -6,4 +6,12 @@ const (
 	PRICE_SUBSCRIPTION = 10000
 	VA_NUMBER          = 12345
 	BANK               = "BCA"
+
+	LLM_PROVIDER_GOOGLEAI    = "googleai"
+	LLM_PROVIDER_HUGGINGFACE = "huggingface"
+
+	DOCUMENT_TYPE_RECEIPT = "receipt"
+
+	// PROMPT_RECEIPT_REPAIR prompt asking the LLM to correct a receipt breaking validation rules
+	PROMPT_RECEIPT_REPAIR = "receipt_repair"
 )
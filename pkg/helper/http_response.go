@@ -3,8 +3,10 @@ package helper
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"net/http"
-	"strings"
+	"rest-app/pkg/apperror"
+	"rest-app/pkg/logging"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,47 +18,51 @@ type Response struct {
 	Message string      `json:"message"`
 }
 
+// ResponseErrorData is the data of a failed Response, Type is one of the stable apperror kinds
 type ResponseErrorData struct {
-	Type string `json:"data"`
-	Code int64  `json:"success"`
+	Type string `json:"type"`
+	Code int64  `json:"code"`
+	// LegacyType and LegacyCode repeat Type and Code under the names errors used before, for the
+	// clients parsing them. Deprecated, to be removed in the next major version
+	LegacyType string `json:"data"`
+	LegacyCode int64  `json:"success"`
 }
 
-func ResponseError(c *gin.Context, err error, opts ...interface{}) {
-	t := "InternalServerError"
-	d := err.Error()
-
-	code := http.StatusInternalServerError
-
+// ResponseError writes err in the Response envelope, the status and type come from its apperror.Kind.
+// Errors that were never classified are answered with a generic 500 and only logged
+func ResponseError(c *gin.Context, err error) {
 	// if request cancelled
 	if c.Request.Context().Err() == context.Canceled {
 		c.AbortWithStatus(http.StatusNoContent)
 		return
 	}
 
-	for _, v := range opts {
-		if tErr, ok := v.(string); ok {
-			if strings.Contains(tErr, " ") {
-				d = tErr
-			} else {
-				t = tErr
-			}
-		}
-		if cErr, ok := v.(int); ok && cErr >= 100 && cErr <= 599 {
-			code = cErr
-		}
+	kind := apperror.Internal
+	message := http.StatusText(http.StatusInternalServerError)
+
+	if appErr, ok := apperror.As(err); ok {
+		kind = appErr.Kind
+		message = appErr.Message
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		kind = apperror.NotFound
+		message = http.StatusText(http.StatusNotFound)
 	}
 
-	if errors.Is(err, gorm.ErrRecordNotFound) || strings.Contains(err.Error(), "not found") {
-		code = http.StatusNotFound
-		t = "NotFound"
+	if kind.Status >= http.StatusInternalServerError {
+		logging.FromContext(c.Request.Context()).Error("request failed",
+			slog.String("type", kind.Type),
+			slog.Any("error", err),
+		)
 	}
 
-	c.AbortWithStatusJSON(code, &Response{
+	c.AbortWithStatusJSON(kind.Status, &Response{
 		Success: false,
-		Message: d,
+		Message: message,
 		Data: &ResponseErrorData{
-			Type: t,
-			Code: int64(code),
+			Type:       kind.Type,
+			Code:       int64(kind.Status),
+			LegacyType: kind.Type,
+			LegacyCode: int64(kind.Status),
 		},
 	})
 }
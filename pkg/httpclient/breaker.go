@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the host while its breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerPolicy opens a host's circuit after FailureThreshold consecutive failures (transport errors or 5xx),
+// after OpenFor a single trial request decides whether it closes again
+type BreakerPolicy struct {
+	FailureThreshold int // 0 disables the breaker
+	OpenFor          time.Duration
+}
+
+// DefaultBreakerPolicy opens after 5 consecutive failures for 30s
+func DefaultBreakerPolicy() BreakerPolicy {
+	return BreakerPolicy{
+		FailureThreshold: 5,
+		OpenFor:          30 * time.Second,
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+type circuitBreaker struct {
+	mu       sync.Mutex
+	policy   BreakerPolicy
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// allow reports whether a request may be sent, in half open state only one trial is let through
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) < b.policy.OpenFor {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.openedAt = now
+		return true
+	case breakerHalfOpen:
+		// a trial that never reported back (e.g. cancelled by its caller) must not keep the circuit stuck
+		if now.Sub(b.openedAt) < b.policy.OpenFor {
+			return false
+		}
+		b.openedAt = now
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) record(success bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.policy.FailureThreshold {
+		b.state = breakerOpen
+		b.openedAt = now
+	}
+}
+
+// breakers holds one circuit breaker per host
+type breakers struct {
+	mu     sync.Mutex
+	policy BreakerPolicy
+	hosts  map[string]*circuitBreaker
+}
+
+// get returns nil when the breaker is disabled
+func (b *breakers) get(host string) *circuitBreaker {
+	if b.policy.FailureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.hosts == nil {
+		b.hosts = map[string]*circuitBreaker{}
+	}
+	cb, ok := b.hosts[host]
+	if !ok {
+		cb = &circuitBreaker{policy: b.policy}
+		b.hosts[host] = cb
+	}
+	return cb
+}
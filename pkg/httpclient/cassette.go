@@ -0,0 +1,340 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CassetteMode selects whether a client talks to the network, records to or replays from a cassette
+type CassetteMode string
+
+const (
+	CassetteOff    CassetteMode = "off"
+	CassetteRecord CassetteMode = "record"
+	CassetteReplay CassetteMode = "replay"
+)
+
+// ErrCassetteMiss is returned in replay mode when no recorded interaction matches a request
+var ErrCassetteMiss = errors.New("no matching interaction in cassette")
+
+// ParseCassetteMode accepts off (or empty), record and replay
+func ParseCassetteMode(mode string) (CassetteMode, error) {
+	switch CassetteMode(strings.ToLower(mode)) {
+	case "", CassetteOff:
+		return CassetteOff, nil
+	case CassetteRecord:
+		return CassetteRecord, nil
+	case CassetteReplay:
+		return CassetteReplay, nil
+	default:
+		return "", fmt.Errorf("unknown cassette mode %q", mode)
+	}
+}
+
+// Cassette is the fixture file format, one file per client
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// CassetteScrub the credentials a recorded cassette never holds. Unlike LogOptions it leaves the
+// payloads alone, a replay has to return the provider output as it was received
+type CassetteScrub struct {
+	// Headers names (case insensitive) whose values are stored as Redacted
+	Headers []string
+	// QueryParams names (case insensitive) whose values are stored as Redacted
+	QueryParams []string
+	// BodyFields top level fields of JSON and form bodies whose values are stored as Redacted
+	BodyFields []string
+}
+
+// DefaultCassetteScrub the credential headers and query params of DefaultLogOptions and the usual token fields
+func DefaultCassetteScrub() CassetteScrub {
+	logOptions := DefaultLogOptions()
+	return CassetteScrub{
+		Headers:     logOptions.RedactHeaders,
+		QueryParams: logOptions.RedactQueryParams,
+		BodyFields:  []string{"api_key", "apikey", "token", "access_token", "refresh_token", "secret", "client_secret", "password"},
+	}
+}
+
+// WithCassette records every interaction to path or replays them from it without touching the network,
+// credentials are scrubbed with DefaultCassetteScrub unless WithCassetteScrub replaces it
+func WithCassette(mode CassetteMode, path string) Option {
+	return func(rc *RestClient) {
+		rc.cassetteMode = mode
+		rc.cassettePath = path
+	}
+}
+
+// WithCassetteScrub replaces DefaultCassetteScrub
+func WithCassetteScrub(scrub CassetteScrub) Option {
+	return func(rc *RestClient) {
+		rc.cassetteScrub = scrub
+	}
+}
+
+// redactURL stores the credential query params as Redacted
+func (s CassetteScrub) redactURL(rawURL string) string {
+	return LogOptions{RedactQueryParams: s.QueryParams}.redactURL(rawURL)
+}
+
+// redactHeaders stores the credential headers as Redacted
+func (s CassetteScrub) redactHeaders(headers http.Header) http.Header {
+	return LogOptions{RedactHeaders: s.Headers}.redactHeaders(headers)
+}
+
+// redactBody stores the credential fields of a JSON or form body as Redacted, other bodies are kept as is
+func (s CassetteScrub) redactBody(body []byte, contentType string) []byte {
+	if len(s.BodyFields) == 0 || len(body) == 0 {
+		return body
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		for name := range form {
+			if containsFold(s.BodyFields, name) {
+				form.Set(name, Redacted)
+			}
+		}
+		return []byte(strings.ReplaceAll(form.Encode(), url.QueryEscape(Redacted), Redacted))
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return body
+	}
+	redacted := false
+	for name := range doc {
+		if containsFold(s.BodyFields, name) {
+			doc[name] = Redacted
+			redacted = true
+		}
+	}
+	if !redacted {
+		return body
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return raw
+}
+
+// cassetteTransport sits below the tracing transport so replayed calls still produce spans
+type cassetteTransport struct {
+	mode  CassetteMode
+	path  string
+	next  http.RoundTripper
+	scrub CassetteScrub
+
+	mu       sync.Mutex
+	loaded   bool
+	cassette Cassette
+	used     []bool
+}
+
+func newCassetteTransport(mode CassetteMode, path string, scrub CassetteScrub, next http.RoundTripper) http.RoundTripper {
+	return &cassetteTransport{
+		mode:  mode,
+		path:  path,
+		next:  next,
+		scrub: scrub,
+	}
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.mode == CassetteReplay {
+		return t.replay(req, body)
+	}
+	return t.record(req, body)
+}
+
+func (t *cassetteTransport) record(req *http.Request, reqBody []byte) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// a recording session always starts a new cassette
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     t.scrub.redactURL(req.URL.String()),
+			Headers: t.scrub.redactHeaders(req.Header),
+			Body:    string(t.scrub.redactBody(reqBody, req.Header.Get("Content-Type"))),
+		},
+		Response: RecordedResponse{
+			Status:  resp.StatusCode,
+			Headers: t.scrub.redactHeaders(resp.Header),
+			Body:    string(respBody),
+		},
+	})
+
+	if err := t.save(); err != nil {
+		return nil, fmt.Errorf("failed to write cassette %s: %w", t.path, err)
+	}
+
+	return resp, nil
+}
+
+// replay serves the first unused interaction with the same method, path and query,
+// preferring one with the same scrubbed body; once all are used the last match is served again
+func (t *cassetteTransport) replay(req *http.Request, body []byte) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.loaded {
+		if err := t.load(); err != nil {
+			return nil, fmt.Errorf("failed to read cassette %s: %w", t.path, err)
+		}
+	}
+
+	key := t.matchKey(req.Method, req.URL.String())
+	scrubbed := string(t.scrub.redactBody(body, req.Header.Get("Content-Type")))
+
+	found, fallback, last := -1, -1, -1
+	for i, interaction := range t.cassette.Interactions {
+		if t.matchKey(interaction.Request.Method, interaction.Request.URL) != key {
+			continue
+		}
+		last = i
+		if t.used[i] {
+			continue
+		}
+		if interaction.Request.Body == scrubbed {
+			found = i
+			break
+		}
+		if fallback < 0 {
+			fallback = i
+		}
+	}
+	if found < 0 {
+		found = fallback
+	}
+	if found < 0 {
+		found = last
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, t.scrub.redactURL(req.URL.String()), ErrCassetteMiss)
+	}
+	t.used[found] = true
+
+	recorded := t.cassette.Interactions[found].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// matchKey ignores the scheme and host so a cassette works against any configured base url
+func (t *cassetteTransport) matchKey(method, rawURL string) string {
+	redacted := t.scrub.redactURL(rawURL)
+	if i := strings.Index(redacted, "://"); i >= 0 {
+		redacted = redacted[i+3:]
+		if j := strings.IndexAny(redacted, "/?"); j >= 0 {
+			redacted = redacted[j:]
+		} else {
+			redacted = ""
+		}
+	}
+	return method + " " + redacted
+}
+
+func (t *cassetteTransport) load() error {
+	raw, err := os.ReadFile(t.path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, &t.cassette); err != nil {
+		return err
+	}
+	t.used = make([]bool, len(t.cassette.Interactions))
+	t.loaded = true
+	return nil
+}
+
+// save rewrites the whole cassette through a temporary file so a crash never leaves half a fixture
+func (t *cassetteTransport) save() error {
+	raw, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// readRequestBody reads the body and puts a fresh reader back for the next transport
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
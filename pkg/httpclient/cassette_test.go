@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCassetteRecordScrubsOnlyCredentials(t *testing.T) {
+	const output = `{"candidates":[{"content":{"parts":[{"text":"{\"amount\": 150000}"}]}}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, output)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "client.json")
+	newClient := func(mode CassetteMode) *RestClient {
+		// the log rules mask the output, cassettes must not use them
+		logOptions := DefaultLogOptions()
+		logOptions.RedactBodyPaths = []string{"candidates.*.content.parts.*.text"}
+		return NewRestClient(time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)),
+			WithLogOptions(logOptions), WithCassette(mode, path))
+	}
+	body := map[string]string{"prompt": "read the receipt", "api_key": "body-key"}
+	headers := map[string]string{"X-Goog-Api-Key": "header-key", "Content-Type": "application/json"}
+
+	recorder := newClient(CassetteRecord)
+	if _, err := recorder.Post(context.Background(), server.URL+"/generate?key=query-key", body, headers); err != nil {
+		t.Fatal(err)
+	}
+	form := url.Values{"secret": {"form-key"}, "response": {"captcha"}}
+	formHeaders := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	if _, err := recorder.Post(context.Background(), server.URL+"/verify", form.Encode(), formHeaders); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"body-key", "header-key", "query-key", "form-key"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("cassette holds %q:\n%s", secret, raw)
+		}
+	}
+	for _, kept := range []string{"read the receipt", "captcha", `150000`} {
+		if !strings.Contains(string(raw), kept) {
+			t.Errorf("cassette lost %q:\n%s", kept, raw)
+		}
+	}
+
+	// the request body is matched once scrubbed, the server is gone
+	server.Close()
+	resp, err := newClient(CassetteReplay).Post(context.Background(), "http://replayed.invalid/generate?key=other-key", body, headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body()) != output {
+		t.Errorf("replayed body = %s, want %s", resp.Body(), output)
+	}
+}
@@ -1,41 +1,89 @@
 package httpclient
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
 	"time"
 
 	"log/slog"
 
 	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"rest-app/pkg/logging"
 )
 
 // HttpClient defines the contract for making HTTP requests
 type HttpClient interface {
-	Get(url string, headers map[string]string, timeout ...time.Duration) (*resty.Response, error)
-	Post(url string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error)
-	Put(url string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error)
-	Delete(url string, headers map[string]string, timeout ...time.Duration) (*resty.Response, error)
+	Get(ctx context.Context, url string, headers map[string]string, timeout ...time.Duration) (*resty.Response, error)
+	Post(ctx context.Context, url string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error)
+	Put(ctx context.Context, url string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error)
+	Delete(ctx context.Context, url string, headers map[string]string, timeout ...time.Duration) (*resty.Response, error)
 }
 
 // RestClient implements HttpClient using Resty
 type RestClient struct {
 	client         *resty.Client
 	logger         *slog.Logger
+	logOptions     LogOptions
+	retryPolicy    RetryPolicy
+	breakers       *breakers
+	cassetteMode   CassetteMode
+	cassettePath   string
+	cassetteScrub  CassetteScrub
 	defaultTimeout time.Duration
 }
 
-// NewRestClient initializes a Resty client with logging and a default timeout
-func NewRestClient(defaultTimeout time.Duration, logger *slog.Logger) *RestClient {
-	client := resty.New().
-		SetTimeout(defaultTimeout) // Set default timeout
-		//SetRetryCount(3).
-		//SetRetryWaitTime(2 * time.Second).
-		//SetRetryMaxWaitTime(10 * time.Second)
+// Option customizes a RestClient
+type Option func(rc *RestClient)
+
+// WithLogOptions replaces DefaultLogOptions
+func WithLogOptions(logOptions LogOptions) Option {
+	return func(rc *RestClient) {
+		rc.logOptions = logOptions
+	}
+}
 
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(retryPolicy RetryPolicy) Option {
+	return func(rc *RestClient) {
+		rc.retryPolicy = retryPolicy
+	}
+}
+
+// WithBreakerPolicy replaces DefaultBreakerPolicy
+func WithBreakerPolicy(breakerPolicy BreakerPolicy) Option {
+	return func(rc *RestClient) {
+		rc.breakers = &breakers{policy: breakerPolicy}
+	}
+}
+
+// NewRestClient initializes a Resty client with logging and a default timeout
+func NewRestClient(defaultTimeout time.Duration, logger *slog.Logger, opts ...Option) *RestClient {
 	rc := &RestClient{
-		client:         client,
 		logger:         logger,
+		logOptions:     DefaultLogOptions(),
+		retryPolicy:    DefaultRetryPolicy(),
+		breakers:       &breakers{policy: DefaultBreakerPolicy()},
+		cassetteScrub:  DefaultCassetteScrub(),
 		defaultTimeout: defaultTimeout,
 	}
+	for _, opt := range opts {
+		opt(rc)
+	}
+
+	transport := http.DefaultTransport
+	if rc.cassetteMode == CassetteRecord || rc.cassetteMode == CassetteReplay {
+		transport = newCassetteTransport(rc.cassetteMode, rc.cassettePath, rc.cassetteScrub, transport)
+	}
+
+	// Timeouts are applied per attempt through the request context, see executeRequest
+	client := resty.New().
+		SetTransport(newTracingTransport(transport))
+	rc.client = client
 
 	// Enable request/response logging
 	client.OnBeforeRequest(rc.logRequest)
@@ -44,36 +92,64 @@ func NewRestClient(defaultTimeout time.Duration, logger *slog.Logger) *RestClien
 	return rc
 }
 
-// logRequest logs outgoing HTTP requests
+// loggerFor prefers the request scoped logger of ctx so outbound calls carry the request id
+func (r *RestClient) loggerFor(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if _, ok := ctx.Value(logging.KeyRequestLogger).(*slog.Logger); ok {
+			return logging.FromContext(ctx)
+		}
+	}
+	return r.logger
+}
+
+// logRequest logs outgoing HTTP requests, credentials are redacted
 func (r *RestClient) logRequest(c *resty.Client, req *resty.Request) error {
-	r.logger.Info("HTTP Request",
+	r.loggerFor(req.Context()).Log(req.Context(), r.logOptions.Level, "HTTP Request",
 		slog.String("method", req.Method),
-		slog.String("url", req.URL),
-		slog.Any("headers", req.Header),
+		slog.String("url", r.logOptions.redactURL(req.URL)),
+		slog.Any("headers", r.logOptions.redactHeaders(req.Header)),
 	)
 	return nil
 }
 
-// logResponse logs incoming HTTP responses
+// logResponse logs incoming HTTP responses, bodies are redacted and truncated
 func (r *RestClient) logResponse(c *resty.Client, resp *resty.Response) error {
+	logger := r.loggerFor(resp.Request.Context())
+
+	level, msg := r.logOptions.Level, "HTTP Response"
 	if resp.IsError() {
-		r.logger.Error("HTTP Response Error",
-			slog.Int("status", resp.StatusCode()),
-			slog.String("url", resp.Request.URL),
-			slog.String("body", resp.String()),
-		)
-	} else {
-		r.logger.Info("HTTP Response",
-			slog.Int("status", resp.StatusCode()),
-			slog.String("url", resp.Request.URL),
-			slog.String("body", resp.String()),
-		)
+		level, msg = slog.LevelError, "HTTP Response Error"
+	}
+
+	if !logger.Enabled(resp.Request.Context(), level) {
+		return nil
 	}
+
+	logger.Log(resp.Request.Context(), level, msg,
+		slog.Int("status", resp.StatusCode()),
+		slog.String("url", r.logOptions.redactURL(resp.Request.URL)),
+		slog.String("body", r.logOptions.redactBody(resp.Body())),
+	)
 	return nil
 }
 
-// executeRequest runs the request with an optional timeout
-func (r *RestClient) executeRequest(method, url string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
+// newTracingTransport creates a client span per request and propagates the W3C trace context
+func newTracingTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithSpanNameFormatter(func(operation string, req *http.Request) string {
+			return "HTTP " + req.Method + " " + req.URL.Host
+		}),
+	)
+}
+
+// executeRequest builds the request once and sends it until it succeeds, fails with a non retryable
+// status, the retry policy is exhausted or ctx is done. Every attempt gets its own timeout.
+func (r *RestClient) executeRequest(ctx context.Context, method, rawURL string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
+	attemptTimeout := r.defaultTimeout
+	if len(timeout) > 0 {
+		attemptTimeout = timeout[0]
+	}
+
 	req := r.client.R()
 
 	// Apply headers if provided
@@ -86,43 +162,93 @@ func (r *RestClient) executeRequest(method, url string, body interface{}, header
 		req.SetBody(body)
 	}
 
-	// Clone the client and apply the timeout dynamically
-	client := r.client
-	if len(timeout) > 0 {
-		client = resty.New().SetTimeout(timeout[0])
+	var host string
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+	cb := r.breakers.get(host)
+
+	maxAttempts := max(r.retryPolicy.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		if cb != nil && !cb.allow(time.Now()) {
+			return nil, fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+		}
+
+		resp, err := r.attempt(ctx, req, method, rawURL, attemptTimeout)
+
+		// the caller giving up says nothing about the host
+		if ctx.Err() != nil {
+			return resp, ctx.Err()
+		}
+
+		failed := err != nil || resp.StatusCode() >= http.StatusInternalServerError
+		if cb != nil {
+			cb.record(!failed, time.Now())
+		}
+
+		if err == nil && !isRetryableStatus(resp.StatusCode()) {
+			return resp, nil
+		}
+		if attempt >= maxAttempts {
+			return resp, err
+		}
+
+		delay := r.retryPolicy.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header().Get("Retry-After"), time.Now()); ok {
+				// not worth blocking the caller for, hand the response back instead
+				if after > r.retryPolicy.MaxDelay {
+					return resp, err
+				}
+				delay = after
+			}
+		}
+
+		r.loggerFor(ctx).Warn("HTTP Request Retry",
+			slog.String("method", method),
+			slog.String("url", r.logOptions.redactURL(rawURL)),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+
+		if err := sleep(ctx, delay); err != nil {
+			return resp, err
+		}
+	}
+}
+
+// attempt sends req once, bounded by timeout
+func (r *RestClient) attempt(ctx context.Context, req *resty.Request, method, rawURL string, timeout time.Duration) (*resty.Response, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	// Execute request based on the method
-	switch method {
-	case "GET":
-		return client.R().SetHeaders(headers).Get(url)
-	case "POST":
-		return client.R().SetHeaders(headers).SetBody(body).Post(url)
-	case "PUT":
-		return client.R().SetHeaders(headers).SetBody(body).Put(url)
-	case "DELETE":
-		return client.R().SetHeaders(headers).Delete(url)
-	default:
-		return nil, nil
+	resp, err := req.SetContext(ctx).Execute(method, rawURL)
+	if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		return resp, fmt.Errorf("request timed out after %s: %w", timeout, err)
 	}
+	return resp, err
 }
 
 // Get makes a GET request with optional headers and timeout
-func (r *RestClient) Get(url string, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
-	return r.executeRequest("GET", url, nil, headers, timeout...)
+func (r *RestClient) Get(ctx context.Context, url string, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
+	return r.executeRequest(ctx, "GET", url, nil, headers, timeout...)
 }
 
 // Post makes a POST request with optional headers and timeout
-func (r *RestClient) Post(url string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
-	return r.executeRequest("POST", url, body, headers, timeout...)
+func (r *RestClient) Post(ctx context.Context, url string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
+	return r.executeRequest(ctx, "POST", url, body, headers, timeout...)
 }
 
 // Put makes a PUT request with optional headers and timeout
-func (r *RestClient) Put(url string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
-	return r.executeRequest("PUT", url, body, headers, timeout...)
+func (r *RestClient) Put(ctx context.Context, url string, body interface{}, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
+	return r.executeRequest(ctx, "PUT", url, body, headers, timeout...)
 }
 
 // Delete makes a DELETE request with optional headers and timeout
-func (r *RestClient) Delete(url string, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
-	return r.executeRequest("DELETE", url, nil, headers, timeout...)
+func (r *RestClient) Delete(ctx context.Context, url string, headers map[string]string, timeout ...time.Duration) (*resty.Response, error) {
+	return r.executeRequest(ctx, "DELETE", url, nil, headers, timeout...)
 }
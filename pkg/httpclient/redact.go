@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Redacted replaces every redacted value in logs
+const Redacted = "[REDACTED]"
+
+// LogOptions controls what a client logs about its requests and responses
+type LogOptions struct {
+	// Level of request/response lines, failed responses are always logged as errors
+	Level slog.Level
+	// RedactHeaders header names (case insensitive) whose values are never logged
+	RedactHeaders []string
+	// RedactQueryParams query parameter names (case insensitive) whose values are never logged
+	RedactQueryParams []string
+	// RedactBodyPaths dot separated paths into JSON bodies, * matches any key or array index
+	//
+	// Ex: candidates.*.content.parts.*.text
+	RedactBodyPaths []string
+	// MaxBodyBytes logged bodies are cut after that many bytes, 0 disables body logging
+	MaxBodyBytes int
+}
+
+// DefaultLogOptions never logs credentials and keeps bodies short
+func DefaultLogOptions() LogOptions {
+	return LogOptions{
+		Level:             slog.LevelInfo,
+		RedactHeaders:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Goog-Api-Key"},
+		RedactQueryParams: []string{"key", "api_key", "apikey", "token", "access_token", "secret"},
+		MaxBodyBytes:      2048,
+	}
+}
+
+func (o LogOptions) redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+
+	query := u.Query()
+	for name := range query {
+		if containsFold(o.RedactQueryParams, name) {
+			query.Set(name, Redacted)
+		}
+	}
+	u.RawQuery = strings.ReplaceAll(query.Encode(), url.QueryEscape(Redacted), Redacted)
+
+	return u.String()
+}
+
+func (o LogOptions) redactHeaders(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	for name := range redacted {
+		if containsFold(o.RedactHeaders, name) {
+			redacted[name] = []string{Redacted}
+		}
+	}
+	return redacted
+}
+
+// redactBody masks the configured JSON paths and truncates the result, non JSON bodies are only truncated
+func (o LogOptions) redactBody(body []byte) string {
+	if o.MaxBodyBytes <= 0 || len(body) == 0 {
+		return ""
+	}
+
+	body = o.maskBody(body)
+	if len(body) > o.MaxBodyBytes {
+		return fmt.Sprintf("%s...(truncated %d bytes)", body[:o.MaxBodyBytes], len(body)-o.MaxBodyBytes)
+	}
+	return string(body)
+}
+
+// maskBody replaces the values at the configured JSON paths, non JSON bodies are returned as is
+func (o LogOptions) maskBody(body []byte) []byte {
+	if len(o.RedactBodyPaths) == 0 || len(body) == 0 {
+		return body
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return body
+	}
+	for _, path := range o.RedactBodyPaths {
+		doc = redactPath(doc, strings.Split(path, "."))
+	}
+	masked, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return masked
+}
+
+func redactPath(node interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return Redacted
+	}
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if path[0] == "*" || path[0] == key {
+				v[key] = redactPath(child, path[1:])
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				v[i] = redactPath(child, path[1:])
+			}
+		}
+	}
+
+	return node
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
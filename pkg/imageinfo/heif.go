@@ -0,0 +1,84 @@
+package imageinfo
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errInvalidHEIF = errors.New("invalid heif container")
+
+// heifSize reads the image spatial extents (ispe) properties of a HEIF file,
+// the largest one is returned so grid images are checked against their full size
+func heifSize(data []byte) (width, height int, err error) {
+	meta, ok := findBox(data, "meta")
+	if !ok || len(meta) < 4 {
+		return 0, 0, errInvalidHEIF
+	}
+	// meta is a full box, skip version and flags
+	iprp, ok := findBox(meta[4:], "iprp")
+	if !ok {
+		return 0, 0, errInvalidHEIF
+	}
+	ipco, ok := findBox(iprp, "ipco")
+	if !ok {
+		return 0, 0, errInvalidHEIF
+	}
+
+	err = walkBoxes(ipco, func(boxType string, payload []byte) bool {
+		// full box header then two uint32
+		if boxType == "ispe" && len(payload) >= 12 {
+			width = max(width, int(binary.BigEndian.Uint32(payload[4:8])))
+			height = max(height, int(binary.BigEndian.Uint32(payload[8:12])))
+		}
+		return true
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if width == 0 || height == 0 {
+		return 0, 0, errInvalidHEIF
+	}
+	return width, height, nil
+}
+
+// findBox returns the payload of the first box of boxType at this level
+func findBox(data []byte, boxType string) ([]byte, bool) {
+	var found []byte
+	err := walkBoxes(data, func(t string, payload []byte) bool {
+		if t == boxType {
+			found = payload
+			return false
+		}
+		return true
+	})
+	return found, err == nil && found != nil
+}
+
+// walkBoxes calls fn with the type and payload of every ISO BMFF box at this level until fn returns false
+func walkBoxes(data []byte, fn func(boxType string, payload []byte) bool) error {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		boxType := string(data[4:8])
+		header := uint64(8)
+
+		switch size {
+		case 0: // box extends to the end
+			size = uint64(len(data))
+		case 1: // 64 bit size follows the type
+			if len(data) < 16 {
+				return errInvalidHEIF
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+
+		if size < header || size > uint64(len(data)) {
+			return errInvalidHEIF
+		}
+		if !fn(boxType, data[header:size]) {
+			return nil
+		}
+		data = data[size:]
+	}
+	return nil
+}
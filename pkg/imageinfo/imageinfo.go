@@ -0,0 +1,116 @@
+package imageinfo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// Format image container detected from magic bytes, named like the image package registers them
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	PNG  Format = "png"
+	WebP Format = "webp"
+	TIFF Format = "tiff"
+	HEIC Format = "heic"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown image format")
+	ErrTooLarge      = errors.New("image dimensions exceed the limits")
+	ErrNoDecoder     = errors.New("no decoder registered for the image format")
+)
+
+// Config size of an image read from its header, without decoding pixels
+type Config struct {
+	Format Format
+	Width  int
+	Height int
+}
+
+// Pixels width times height
+func (c Config) Pixels() int64 {
+	return int64(c.Width) * int64(c.Height)
+}
+
+// Limits caps image dimensions before decoding, zero values are unlimited
+type Limits struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int64
+}
+
+// Check returns ErrTooLarge when c exceeds l
+func (l Limits) Check(c Config) error {
+	switch {
+	case l.MaxWidth > 0 && c.Width > l.MaxWidth,
+		l.MaxHeight > 0 && c.Height > l.MaxHeight:
+		return fmt.Errorf("%w: %dx%d, max %dx%d", ErrTooLarge, c.Width, c.Height, l.MaxWidth, l.MaxHeight)
+	case l.MaxPixels > 0 && c.Pixels() > l.MaxPixels:
+		return fmt.Errorf("%w: %d pixels, max %d", ErrTooLarge, c.Pixels(), l.MaxPixels)
+	}
+	return nil
+}
+
+// heifBrands ftyp brands of HEIF still images
+var heifBrands = map[string]bool{
+	"heic": true, "heix": true, "heim": true, "heis": true,
+	"hevc": true, "hevx": true, "mif1": true, "msf1": true,
+}
+
+// Sniff detects the format from the first bytes of data
+func Sniff(data []byte) (Format, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return JPEG, nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return PNG, nil
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return WebP, nil
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return TIFF, nil
+	case len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")) && heifBrands[string(data[8:12])]:
+		return HEIC, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// DecodeConfig sniffs data and reads its dimensions from the header only
+func DecodeConfig(data []byte) (Config, error) {
+	format, err := Sniff(data)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if format == HEIC {
+		width, height, err := heifSize(data)
+		if err != nil {
+			return Config{}, err
+		}
+		return Config{Format: format, Width: width, Height: height}, nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid %s header: %w", format, err)
+	}
+	return Config{Format: format, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// Decode decodes data with the Go decoders registered in the image package,
+// HEIC only decodes when a HEIF decoder was registered with image.RegisterFormat
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, ErrNoDecoder
+	}
+	return img, err
+}
@@ -0,0 +1,85 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Hook start and stop functions of a component, either may be nil
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Manager starts components in the order they were appended and stops them in reverse,
+// so a component is stopped before the ones it depends on
+type Manager struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	started int
+}
+
+func New() *Manager {
+	return &Manager{}
+}
+
+// Append registers a component, hooks appended after Start are never started
+func (m *Manager) Append(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Start runs every OnStart in order, on failure the components already started are stopped
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for m.started < len(m.hooks) {
+		hook := m.hooks[m.started]
+		if hook.OnStart != nil {
+			if err := hook.OnStart(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", hook.Name, err)
+				if stopErr := m.stop(ctx); stopErr != nil {
+					return errors.Join(err, stopErr)
+				}
+				return err
+			}
+		}
+		m.started++
+	}
+	return nil
+}
+
+// Stop runs OnStop of the started components in reverse order. Every component gets to stop
+// even when an earlier one failed or ctx expired, the errors are joined
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stop(ctx)
+}
+
+func (m *Manager) stop(ctx context.Context) error {
+	var errs []error
+	for ; m.started > 0; m.started-- {
+		hook := m.hooks[m.started-1]
+		if hook.OnStop == nil {
+			continue
+		}
+
+		start := time.Now()
+		err := hook.OnStop(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", hook.Name, err))
+			slog.Error("component stop failed", slog.String("component", hook.Name), slog.Duration("duration", time.Since(start)), slog.Any("error", err))
+			continue
+		}
+		slog.Info("component stopped", slog.String("component", hook.Name), slog.Duration("duration", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// KeyLogger type for key context value logger
+type KeyLogger string
+
+// KeyRequestLogger concrete type for key context value request scoped logger
+const KeyRequestLogger KeyLogger = KeyLogger("rest-app-logger")
+
+// New creates the app logger, format is json (default) or text
+func New(w io.Writer, format, level string) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level: ParseLevel(level),
+	}
+
+	if strings.EqualFold(format, "text") {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// ParseLevel maps debug, info, warn and error, anything else is info
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// WithLogger returns a copy of ctx carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, KeyRequestLogger, logger)
+}
+
+// FromContext returns the request scoped logger, or the default logger outside of a request
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(KeyRequestLogger).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "rest_app"
+
+// OCR pipeline stages
+const (
+	StageDecode     = "decode"
+	StagePreprocess = "preprocess"
+	StageTesseract  = "tesseract"
+	StageLLM        = "llm"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+	}, []string{"method", "route", "status"})
+
+	OCRStageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ocr_stage_duration_seconds",
+		Help:      "Duration of each OCR pipeline stage.",
+		Buckets:   []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"stage"})
+
+	OCRPoolWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ocr_pool_wait_seconds",
+		Help:      "Time spent waiting for an idle tesseract client.",
+		Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30},
+	})
+
+	LLMTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "LLM tokens used by provider, model and type (prompt, candidates, total).",
+	}, []string{"provider", "model", "type"})
+
+	LLMErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_errors_total",
+		Help:      "LLM provider errors by provider and reason.",
+	}, []string{"provider", "reason"})
+
+	ExtractionRepairs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "extraction_repairs_total",
+		Help:      "Repair round-trips of receipts breaking validation rules by outcome (fixed, partial, rejected, failed).",
+	}, []string{"outcome"})
+
+	InstitutionDetections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "institution_detections_total",
+		Help:      "Receipts by the bank or e-wallet detected as their issuer and how (text, logo), none when no institution was.",
+	}, []string{"institution", "by"})
+)
+
+// ObserveStage records the duration of an OCR stage started at start
+//
+// Usage: defer metrics.ObserveStage(metrics.StageDecode, time.Now())
+func ObserveStage(stage string, start time.Time) {
+	OCRStageDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+}
+
+// ocrPool the size and usage of the pool last registered
+type ocrPool struct {
+	size  func() int
+	inUse func() int
+}
+
+var (
+	currentOCRPool  atomic.Pointer[ocrPool]
+	registerOCRPool sync.Once
+)
+
+// RegisterOCRPool exposes the size and usage of the tesseract pool. The gauges are registered
+// once, calling it again, e.g. when setup runs twice in a process, reports the new pool instead
+func RegisterOCRPool(size func() int, inUse func() int) {
+	currentOCRPool.Store(&ocrPool{size: size, inUse: inUse})
+	registerOCRPool.Do(func() {
+		prometheus.MustRegister(
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "ocr_pool_size",
+				Help:      "Number of tesseract clients in the pool.",
+			}, func() float64 { return float64(currentOCRPool.Load().size()) }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "ocr_pool_in_use",
+				Help:      "Number of tesseract clients currently busy.",
+			}, func() float64 { return float64(currentOCRPool.Load().inUse()) }),
+		)
+	})
+}
@@ -0,0 +1,200 @@
+package normalize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// months month names and abbreviations in Indonesian and English
+var months = map[string]time.Month{
+	"jan": time.January, "januari": time.January, "january": time.January,
+	"feb": time.February, "februari": time.February, "february": time.February, "peb": time.February, "pebruari": time.February,
+	"mar": time.March, "maret": time.March, "march": time.March,
+	"apr": time.April, "april": time.April,
+	"mei": time.May, "may": time.May,
+	"jun": time.June, "juni": time.June, "june": time.June,
+	"jul": time.July, "juli": time.July, "july": time.July,
+	"agu": time.August, "agt": time.August, "agus": time.August, "agustus": time.August, "aug": time.August, "august": time.August,
+	"sep": time.September, "sept": time.September, "september": time.September,
+	"okt": time.October, "oktober": time.October, "oct": time.October, "october": time.October,
+	"nov": time.November, "nop": time.November, "nopember": time.November, "november": time.November,
+	"des": time.December, "desember": time.December, "dec": time.December, "december": time.December,
+}
+
+// weekdays day names receipts print before the date
+var weekdays = regexp.MustCompile(`^(senin|selasa|rabu|kamis|jumat|jum'at|sabtu|minggu|monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tue|wed|thu|fri|sat|sun),?\s+`)
+
+var (
+	// yearFirst 2025-05-12, 2025/05/12
+	yearFirst = regexp.MustCompile(`^(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})$`)
+	// dayFirst 12/05/2025, 12-05-25, Indonesian receipts put the day first
+	dayFirst = regexp.MustCompile(`^(\d{1,2})[-/.](\d{1,2})[-/.](\d{4}|\d{2})$`)
+	// dayMonthName 12 Mei 2025, 12-May-25, 12 Agt. 2025
+	dayMonthName = regexp.MustCompile(`^(\d{1,2})[\s-]+([a-z]+)\.?[\s-]+(\d{4}|\d{2})$`)
+	// monthNameDay May 12, 2025
+	monthNameDay = regexp.MustCompile(`^([a-z]+)\.?\s+(\d{1,2}),?\s+(\d{4})$`)
+)
+
+// clock 09:30, 09.30.15, 9:30 PM, 21:30:15 WIB, 21:30 +07:00, 21:30 GMT+7
+var clock = regexp.MustCompile(`^(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?\s*(am|pm)?\s*(wib|wita|wit|utc|gmt|z|(?:gmt|utc)?[+-]\d{1,2}(?::?\d{2})?)?$`)
+
+// utcOffset sign, hours and minutes of an offset
+var utcOffset = regexp.MustCompile(`^(?:gmt|utc)?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// zones of Indonesia by the abbreviations receipts print
+var zones = map[string]*time.Location{
+	"wib":  time.FixedZone("WIB", 7*60*60),
+	"wita": time.FixedZone("WITA", 8*60*60),
+	"wit":  time.FixedZone("WIT", 9*60*60),
+	"utc":  time.UTC,
+	"gmt":  time.UTC,
+	"z":    time.UTC,
+}
+
+// Clock a time of day, Location is nil when the receipt names no timezone
+type Clock struct {
+	Hour, Minute, Second int
+	Location             *time.Location
+}
+
+// Date parses the date formats of Indonesian bank and e-wallet receipts, with month names in
+// Indonesian or English. Numeric dates are read day first, two digit years are in the 2000s
+//
+// Ex: "Senin, 12 Mei 2025", "12/05/25", "2025-05-12", "May 12, 2025" => 2025-05-12 00:00 UTC
+func Date(value string) (time.Time, bool) {
+	s := strings.Join(strings.Fields(strings.ToLower(value)), " ")
+	s = weekdays.ReplaceAllString(s, "")
+
+	var year, month, day string
+	if m := yearFirst.FindStringSubmatch(s); m != nil {
+		year, month, day = m[1], m[2], m[3]
+	} else if m := dayFirst.FindStringSubmatch(s); m != nil {
+		day, month, year = m[1], m[2], m[3]
+	} else if m := dayMonthName.FindStringSubmatch(s); m != nil {
+		day, month, year = m[1], m[2], m[3]
+	} else if m := monthNameDay.FindStringSubmatch(s); m != nil {
+		month, day, year = m[1], m[2], m[3]
+	} else {
+		return time.Time{}, false
+	}
+
+	y, _ := strconv.Atoi(year)
+	if len(year) == 2 {
+		y += 2000
+	}
+	d, _ := strconv.Atoi(day)
+	mon, ok := months[month]
+	if !ok {
+		n, err := strconv.Atoi(month)
+		if err != nil {
+			return time.Time{}, false
+		}
+		mon = time.Month(n)
+	}
+
+	date := time.Date(y, mon, d, 0, 0, 0, 0, time.UTC)
+	// time.Date normalizes 31/02 into March, such a date doesn't exist
+	if date.Year() != y || date.Month() != mon || date.Day() != d {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// Time parses a time of day in 24 or 12 hour format, with an optional timezone: WIB, WITA, WIT,
+// UTC or an offset
+//
+// Ex: "09.30 WIB", "9:30:15 PM", "21:30 +07:00"
+func Time(value string) (Clock, bool) {
+	m := clock.FindStringSubmatch(strings.Join(strings.Fields(strings.ToLower(value)), " "))
+	if m == nil {
+		return Clock{}, false
+	}
+
+	c := Clock{}
+	c.Hour, _ = strconv.Atoi(m[1])
+	c.Minute, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		c.Second, _ = strconv.Atoi(m[3])
+	}
+	switch m[4] {
+	case "am", "pm":
+		if c.Hour < 1 || c.Hour > 12 {
+			return Clock{}, false
+		}
+		c.Hour %= 12
+		if m[4] == "pm" {
+			c.Hour += 12
+		}
+	}
+	if c.Hour > 23 || c.Minute > 59 || c.Second > 59 {
+		return Clock{}, false
+	}
+
+	if m[5] != "" {
+		loc, ok := zone(m[5])
+		if !ok {
+			return Clock{}, false
+		}
+		c.Location = loc
+	}
+	return c, true
+}
+
+// zone a timezone abbreviation or an offset: +07, +0700, +07:00, GMT+7
+func zone(value string) (*time.Location, bool) {
+	if loc, ok := zones[value]; ok {
+		return loc, true
+	}
+
+	m := utcOffset.FindStringSubmatch(value)
+	if m == nil {
+		return nil, false
+	}
+	hours, _ := strconv.Atoi(m[2])
+	minutes, _ := strconv.Atoi(m[3])
+	if hours > 14 || minutes > 59 {
+		return nil, false
+	}
+	seconds := hours*60*60 + minutes*60
+	if m[1] == "-" {
+		seconds = -seconds
+	}
+	if seconds == 0 {
+		return time.UTC, true
+	}
+	return time.FixedZone("", seconds), true
+}
+
+// Timestamp combines the date and the time of a receipt, loc applies when the time names no
+// timezone. An RFC 3339 date is used as is, a date printed with its time is split when value
+// of time is empty. Without a time ok is false, the date alone is in date
+func Timestamp(dateValue, timeValue string, loc *time.Location) (timestamp time.Time, date time.Time, ok bool) {
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(dateValue)); err == nil {
+		return t, time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), true
+	}
+
+	date, dateOK := Date(dateValue)
+	if !dateOK && strings.TrimSpace(timeValue) == "" {
+		// "12 Mei 2025 09:30 WIB", try every split between the date and the time
+		fields := strings.Fields(dateValue)
+		for i := len(fields) - 1; i > 0 && !dateOK; i-- {
+			if date, dateOK = Date(strings.Join(fields[:i], " ")); dateOK {
+				timeValue = strings.Join(fields[i:], " ")
+			}
+		}
+	}
+	if !dateOK {
+		return time.Time{}, time.Time{}, false
+	}
+
+	c, ok := Time(timeValue)
+	if !ok {
+		return time.Time{}, date, false
+	}
+	if c.Location != nil {
+		loc = c.Location
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), c.Hour, c.Minute, c.Second, 0, loc), date, true
+}
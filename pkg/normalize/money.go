@@ -0,0 +1,116 @@
+package normalize
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount = errors.New("not a decimal amount")
+	ErrAmountTooLong = errors.New("amount doesn't fit in 64 bits of minor units")
+)
+
+// currencyExponents ISO 4217 digits of the minor unit of the currencies receipts of Indonesia are in
+var currencyExponents = map[string]int{
+	"IDR": 2, "USD": 2, "SGD": 2, "MYR": 2, "EUR": 2, "GBP": 2, "AUD": 2, "CNY": 2, "HKD": 2,
+	"THB": 2, "PHP": 2, "SAR": 2, "AED": 2, "TWD": 2, "JPY": 0, "KRW": 0, "VND": 0,
+}
+
+// currencyAliases symbols and names receipts print instead of the ISO code, lower cased
+var currencyAliases = map[string]string{
+	"rp": "IDR", "rupiah": "IDR",
+	"$": "USD", "us$": "USD", "dollar": "USD",
+	"s$": "SGD", "sgd$": "SGD",
+	"rm": "MYR", "ringgit": "MYR",
+	"€": "EUR", "£": "GBP", "a$": "AUD", "rmb": "CNY", "hk$": "HKD", "฿": "THB", "₱": "PHP", "₩": "KRW", "₫": "VND",
+}
+
+// Currency the ISO 4217 code of a code, symbol or name, ok is false for an unknown currency
+//
+// Ex: "Rp", "Rp.", "idr" => IDR, "US$" => USD
+func Currency(value string) (string, bool) {
+	s := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), ".")
+	code := strings.ToUpper(s)
+	if _, ok := currencyExponents[code]; ok {
+		return code, true
+	}
+	code, ok := currencyAliases[s]
+	return code, ok
+}
+
+// CurrencyExponent digits of the minor unit of an ISO 4217 code, 2 for an unknown one
+func CurrencyExponent(code string) int {
+	if exponent, ok := currencyExponents[code]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// printedAmount digits with thousand and decimal separators
+var printedAmount = regexp.MustCompile(`[0-9][0-9.,]*`)
+
+// Decimal the first amount printed in value as a decimal literal. Both separators are read the
+// Indonesian (150.000,00) and the English (150,000.00) way: with both the last one is the decimal
+// point, a single one is a thousand separator when it is repeated or followed by three digits
+//
+// Ex: "Rp 150.000,00" => 150000.00, "IDR 150,000.00" => 150000.00, "Rp1.500" => 1500, "2,5" => 2.5
+func Decimal(value string) (string, bool) {
+	amount := strings.TrimRight(printedAmount.FindString(value), ".,")
+	if amount == "" {
+		return "", false
+	}
+
+	decimalPoint := -1
+	if last := strings.LastIndexAny(amount, ".,"); last >= 0 {
+		separator := amount[last]
+		other := byte('.')
+		if separator == '.' {
+			other = ','
+		}
+		switch {
+		case strings.IndexByte(amount, other) >= 0:
+			decimalPoint = last
+		case strings.Count(amount, string(separator)) == 1 && len(amount)-last-1 != 3:
+			decimalPoint = last
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(amount); i++ {
+		switch {
+		case i == decimalPoint:
+			b.WriteByte('.')
+		case amount[i] != '.' && amount[i] != ',':
+			b.WriteByte(amount[i])
+		}
+	}
+	return b.String(), true
+}
+
+// MinorUnits converts a decimal number, a JSON number literal, into minor units of exponent
+// digits without going through a float. Digits past the minor unit are rounded half away from zero
+//
+// Ex: ("150000", 2) => 15000000, ("10.005", 2) => 1001, ("1.5e3", 0) => 1500
+func MinorUnits(amount string, exponent int) (int64, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return 0, ErrInvalidAmount
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)))
+
+	// round half away from zero: truncate |r| + 1/2
+	half := big.NewRat(1, 2)
+	abs := new(big.Rat).Abs(r)
+	abs.Add(abs, half)
+	minor := new(big.Int).Quo(abs.Num(), abs.Denom())
+	if r.Sign() < 0 {
+		minor.Neg(minor)
+	}
+
+	if !minor.IsInt64() {
+		return 0, ErrAmountTooLong
+	}
+	return minor.Int64(), nil
+}
@@ -0,0 +1,40 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Route a method and path served by the app
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Drift lists the routes missing from the document and the documented operations no route serves
+func (d *Document) Drift(routes []Route) []string {
+	served := map[Route]bool{}
+	for _, r := range routes {
+		served[Route{Method: r.Method, Path: FromGinPath(r.Path)}] = true
+	}
+
+	documented := map[Route]bool{}
+	for _, r := range d.Operations() {
+		documented[r] = true
+	}
+
+	var drift []string
+	for r := range served {
+		if !documented[r] {
+			drift = append(drift, fmt.Sprintf("%s %s is served but not documented", r.Method, r.Path))
+		}
+	}
+	for r := range documented {
+		if !served[r] {
+			drift = append(drift, fmt.Sprintf("%s %s is documented but not served", r.Method, r.Path))
+		}
+	}
+	sort.Strings(drift)
+
+	return drift
+}
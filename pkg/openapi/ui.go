@@ -0,0 +1,54 @@
+package openapi
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"strings"
+)
+
+//go:embed ui.html
+var uiTemplate string
+
+// uiAssets the swagger-ui-dist release pinned in swagger-ui/VERSION, the build fails until
+// scripts/fetch-swagger-ui.sh (run by make and the Docker build) has fetched it
+//
+//go:embed swagger-ui/VERSION swagger-ui/swagger-ui.css swagger-ui/swagger-ui-bundle.js
+var uiAssets embed.FS
+
+// UIVersion pinned swagger-ui-dist release
+var UIVersion = strings.TrimSpace(string(mustReadUIAsset("VERSION")))
+
+var uiPage = template.Must(template.New("ui").Parse(uiTemplate))
+
+// UI renders the Swagger UI page for the spec served at specURL, assetsURL serves UIAssets
+func UI(title, specURL, assetsURL string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := uiPage.Execute(&buf, map[string]string{
+		"Title":     title,
+		"SpecURL":   specURL,
+		"AssetsURL": assetsURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UIAssets the embedded script and stylesheet of the UI
+func UIAssets() fs.FS {
+	assets, err := fs.Sub(uiAssets, "swagger-ui")
+	if err != nil {
+		panic(err)
+	}
+	return assets
+}
+
+func mustReadUIAsset(name string) []byte {
+	b, err := uiAssets.ReadFile("swagger-ui/" + name)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"rest-app/pkg/cache"
+)
+
+// cacheLimiter stores buckets in an ICache. Its read-modify-write is only serialized within
+// the process, so it can't share limits between instances even over a shared cache; the app
+// doesn't use it until there is a shared backend with an atomic update.
+type cacheLimiter struct {
+	mu     sync.Mutex
+	cache  cache.ICache
+	prefix string
+}
+
+func NewCacheLimiter(c cache.ICache) ILimiter {
+	return &cacheLimiter{
+		cache:  c,
+		prefix: "ratelimit:",
+	}
+}
+
+func (l *cacheLimiter) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var b bucket
+	raw, err := l.cache.Get(ctx, l.prefix+key)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(raw, &b); err != nil {
+			b = bucket{}
+		}
+	case !errors.Is(err, cache.ErrCacheMiss):
+		return Result{}, err
+	}
+
+	res := b.take(time.Now(), limit)
+
+	raw, err = json.Marshal(b)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := l.cache.Set(ctx, l.prefix+key, raw, b.ttl(limit)); err != nil {
+		return Result{}, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryLimiter keeps buckets in process, limits are per instance
+type memoryLimiter struct {
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastEvicted time.Time
+}
+
+func NewMemoryLimiter() ILimiter {
+	return &memoryLimiter{
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (m *memoryLimiter) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{}
+		m.buckets[key] = b
+	}
+	res := b.take(now, limit)
+
+	m.evictIdle(now, limit)
+
+	return res, nil
+}
+
+// evictIdle drops buckets that would be full again, caller must hold the lock
+func (m *memoryLimiter) evictIdle(now time.Time, limit Limit) {
+	ttl := (&bucket{}).ttl(limit)
+	if ttl == 0 || now.Sub(m.lastEvicted) < ttl {
+		return
+	}
+	m.lastEvicted = now
+
+	for key, b := range m.buckets {
+		if now.Sub(b.Last) > ttl {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"rest-app/pkg/cache"
+)
+
+// QuotaResult outcome of consuming a monthly quota unit
+type QuotaResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	ResetAt   time.Time // start of the next period
+}
+
+// IQuota monthly usage counter
+type IQuota interface {
+	Consume(ctx context.Context, key string, limit int) (QuotaResult, error)
+	Refund(ctx context.Context, key string) error
+}
+
+type cacheQuota struct {
+	mu     sync.Mutex
+	cache  cache.ICache
+	prefix string
+	now    func() time.Time
+}
+
+// NewCacheQuota counts usage per calendar month (UTC) in the given cache. With the process local
+// cache every replica counts on its own and a restart resets the counts, use NewDBQuota to enforce
+// the monthly quotas
+func NewCacheQuota(c cache.ICache) IQuota {
+	return &cacheQuota{
+		cache:  c,
+		prefix: "quota:",
+		now:    time.Now,
+	}
+}
+
+func (q *cacheQuota) period(now time.Time) (key string, resetAt time.Time) {
+	start := monthStart(now)
+	return start.Format("2006-01"), start.AddDate(0, 1, 0)
+}
+
+func (q *cacheQuota) usage(ctx context.Context, key string) (int, error) {
+	raw, err := q.cache.Get(ctx, key)
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	used, _ := strconv.Atoi(string(raw))
+	return used, nil
+}
+
+// Consume counts one unit against key, a negative limit means unlimited
+func (q *cacheQuota) Consume(ctx context.Context, key string, limit int) (QuotaResult, error) {
+	now := q.now()
+	period, resetAt := q.period(now)
+	cacheKey := q.prefix + key + ":" + period
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	used, err := q.usage(ctx, cacheKey)
+	if err != nil {
+		return QuotaResult{}, err
+	}
+
+	res := QuotaResult{Limit: limit, ResetAt: resetAt}
+	if limit >= 0 && used >= limit {
+		return res, nil
+	}
+
+	used++
+	if err := q.cache.Set(ctx, cacheKey, []byte(strconv.Itoa(used)), resetAt.Sub(now)+time.Hour); err != nil {
+		return QuotaResult{}, err
+	}
+
+	res.Allowed = true
+	if limit >= 0 {
+		res.Remaining = limit - used
+	}
+
+	return res, nil
+}
+
+// Refund gives back a unit consumed in the current period
+func (q *cacheQuota) Refund(ctx context.Context, key string) error {
+	now := q.now()
+	period, resetAt := q.period(now)
+	cacheKey := q.prefix + key + ":" + period
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	used, err := q.usage(ctx, cacheKey)
+	if err != nil || used == 0 {
+		return err
+	}
+
+	return q.cache.Set(ctx, cacheKey, []byte(strconv.Itoa(used-1)), resetAt.Sub(now)+time.Hour)
+}
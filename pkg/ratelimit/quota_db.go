@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"rest-app/pkg/tenant"
+)
+
+type dbQuota struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewDBQuota counts usage per calendar month (UTC) in the ocr_quota_usage table, shared by every
+// replica and kept across restarts. A unit is consumed atomically, concurrent requests can't
+// exceed the limit. Rows are stored with the tenant of the context, anonymous ones without
+// tenant
+func NewDBQuota(db *gorm.DB) IQuota {
+	return &dbQuota{db: db, now: time.Now}
+}
+
+func monthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Consume counts one unit against key, a negative limit means unlimited
+func (q *dbQuota) Consume(ctx context.Context, key string, limit int) (QuotaResult, error) {
+	period := monthStart(q.now())
+	res := QuotaResult{Limit: limit, ResetAt: period.AddDate(0, 1, 0)}
+	if limit == 0 {
+		return res, nil
+	}
+
+	var tenantID *string
+	if id, ok := tenant.FromContext(ctx); ok {
+		tenantID = &id
+	}
+
+	// the row is only incremented below the limit, no row comes back once it is reached
+	var used []int
+	err := q.db.WithContext(ctx).Raw(`
+		INSERT INTO ocr_quota_usage (key, tenant_id, period, used) VALUES (?, ?, ?, 1)
+		ON CONFLICT (key, period) DO UPDATE SET used = ocr_quota_usage.used + 1, updated_at = NOW()
+		WHERE ? < 0 OR ocr_quota_usage.used < ?
+		RETURNING used`, key, tenantID, period, limit, limit).Scan(&used).Error
+	if err != nil {
+		return QuotaResult{}, err
+	}
+	if len(used) == 0 {
+		return res, nil
+	}
+
+	res.Allowed = true
+	if limit >= 0 {
+		res.Remaining = limit - used[0]
+	}
+	return res, nil
+}
+
+// Refund gives back a unit consumed in the current period
+func (q *dbQuota) Refund(ctx context.Context, key string) error {
+	return q.db.WithContext(ctx).Exec(`
+		UPDATE ocr_quota_usage SET used = used - 1, updated_at = NOW()
+		WHERE key = ? AND period = ? AND used > 0`, key, monthStart(q.now())).Error
+}
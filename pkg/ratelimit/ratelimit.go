@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"rest-app/pkg/apperror"
+	"time"
+)
+
+var (
+	ErrRateLimited   = apperror.New(apperror.RateLimited, "Too Many Requests")
+	ErrQuotaExceeded = apperror.New(apperror.QuotaExceeded, "Monthly OCR quota exceeded")
+)
+
+// Limit token bucket policy, Burst tokens refilled at Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next token, zero when allowed
+}
+
+// ILimiter token bucket backend
+type ILimiter interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket state of a single token bucket
+type bucket struct {
+	Tokens float64   `json:"tokens"`
+	Last   time.Time `json:"last"`
+}
+
+// take refills the bucket up to now and tries to consume one token
+func (b *bucket) take(now time.Time, limit Limit) Result {
+	if b.Last.IsZero() {
+		b.Tokens = float64(limit.Burst)
+	} else if elapsed := now.Sub(b.Last).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(float64(limit.Burst), b.Tokens+elapsed*limit.Rate)
+	}
+	b.Last = now
+
+	res := Result{Limit: limit.Burst}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		res.Allowed = true
+	} else if limit.Rate > 0 {
+		res.RetryAfter = secondsToDuration((1 - b.Tokens) / limit.Rate)
+	}
+
+	res.Remaining = int(math.Floor(b.Tokens))
+	if limit.Rate > 0 {
+		res.ResetAfter = secondsToDuration((float64(limit.Burst) - b.Tokens) / limit.Rate)
+	}
+
+	return res
+}
+
+// ttl how long an idle bucket has to be kept before it is full again anyway
+func (b *bucket) ttl(limit Limit) time.Duration {
+	if limit.Rate <= 0 {
+		return 0
+	}
+	return secondsToDuration(float64(limit.Burst)/limit.Rate) + time.Second
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,74 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KeyTenant type for key context value tenant
+type KeyTenant string
+
+// KeyTenantID concrete type for key context value tenant id
+const KeyTenantID KeyTenant = KeyTenant("rest-app-tenant-id")
+
+// ColumnTenantID column name carried by every tenant owned table
+const ColumnTenantID = "tenant_id"
+
+// ErrNoTenant a tenant owned row is created without a tenant in the row or the context
+var ErrNoTenant = errors.New("tenant owned row created without a tenant")
+
+// WithTenantID returns a copy of ctx carrying the given tenant id
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, KeyTenantID, tenantID)
+}
+
+// FromContext returns the tenant id bound to ctx, if any
+func FromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(KeyTenantID).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// Scope filters every statement whose model has a tenant_id column by the given tenant.
+// Models without the column (or raw SQL) are left untouched.
+func Scope(tenantID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		model := db.Statement.Model
+		if model == nil {
+			model = db.Statement.Dest
+		}
+		if model == nil || db.Statement.Parse(model) != nil {
+			return db
+		}
+
+		if db.Statement.Schema.LookUpField(ColumnTenantID) == nil {
+			return db
+		}
+
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: ColumnTenantID},
+			Value:  tenantID,
+		})
+	}
+}
+
+// Owned is embedded by models stored in tenant owned tables, it stamps the
+// tenant id from the statement context on create and refuses to create a row without one.
+type Owned struct {
+	TenantID string `json:"tenant_id" gorm:"column:tenant_id"`
+}
+
+// BeforeCreate gorm hook
+func (o *Owned) BeforeCreate(tx *gorm.DB) error {
+	if o.TenantID != "" {
+		return nil
+	}
+	tenantID, ok := FromContext(tx.Statement.Context)
+	if !ok {
+		return ErrNoTenant
+	}
+	o.TenantID = tenantID
+	return nil
+}
@@ -0,0 +1,142 @@
+package tesseract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+var ErrPoolClosed = errors.New("tesseract pool is closed")
+
+// Pool a fixed set of tesseract clients, a client isn't safe for concurrent use
+// so every OCR call has to Acquire one and Release it when done
+type Pool struct {
+	clients  chan *gosseract.Client
+	size     int
+	acquired atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewPool(size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+
+	p := &Pool{
+		clients: make(chan *gosseract.Client, size),
+		size:    size,
+	}
+	for i := 0; i < size; i++ {
+		p.clients <- gosseract.NewClient()
+	}
+
+	return p
+}
+
+// Acquire waits for an idle client until ctx is done
+func (p *Pool) Acquire(ctx context.Context) (*gosseract.Client, error) {
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return nil, ErrPoolClosed
+	}
+
+	select {
+	case client, ok := <-p.clients:
+		if !ok {
+			return nil, ErrPoolClosed
+		}
+		p.acquired.Add(1)
+		return client, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Release gives a client back to the pool
+func (p *Pool) Release(client *gosseract.Client) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	defer p.acquired.Add(-1)
+
+	if p.closed {
+		client.Close()
+		return
+	}
+	p.clients <- client
+}
+
+// Size number of clients in the pool
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// InUse number of clients currently acquired
+func (p *Pool) InUse() int {
+	return int(p.acquired.Load())
+}
+
+// Ping checks the pool is open and tesseract is loaded without acquiring a client, a pool whose
+// clients are all busy is saturated, not down
+func (p *Pool) Ping(ctx context.Context) error {
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return ErrPoolClosed
+	}
+
+	if gosseract.Version() == "" {
+		return errors.New("tesseract is not available")
+	}
+
+	return nil
+}
+
+// Close frees the idle clients, acquired clients are freed on Release
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+
+	for {
+		select {
+		case client := <-p.clients:
+			client.Close()
+		default:
+			return
+		}
+	}
+}
+
+// drainPollInterval how often Drain checks for released clients
+const drainPollInterval = 50 * time.Millisecond
+
+// Drain closes the pool, so Acquire fails with ErrPoolClosed, and waits until every acquired
+// client is released or ctx is done
+func (p *Pool) Drain(ctx context.Context) error {
+	p.Close()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for p.InUse() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d tesseract clients still in use: %w", p.InUse(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -3,19 +3,26 @@ package transaction
 import (
 	"context"
 
+	"gorm.io/gorm"
+
 	"rest-app/config/db"
+	"rest-app/pkg/tenant"
 )
 
-// GetTrxContext returns default db instance where context doesn't contains custom db instance
+// GetTrxContext returns default db instance where context doesn't contains custom db instance.
+// When the context carries a tenant, every query is scoped to that tenant.
 func GetTrxContext(c context.Context, defaultDB *db.GormDB) *db.GormDB {
-	tx, ok := c.Value(KeyTransaction).(*db.GormDB)
-	if !ok {
-		return &db.GormDB{
-			DB: defaultDB.DB.WithContext(c),
-		}
+	gormDB := defaultDB.DB
+	if tx, ok := c.Value(KeyTransaction).(*db.GormDB); ok {
+		gormDB = tx.DB
+	}
+	gormDB = gormDB.WithContext(c)
+
+	if tenantID, ok := tenant.FromContext(c); ok {
+		gormDB = gormDB.Scopes(tenant.Scope(tenantID)).Session(&gorm.Session{})
 	}
 
 	return &db.GormDB{
-		DB: tx.DB.WithContext(c),
+		DB: gormDB,
 	}
 }
@@ -0,0 +1,45 @@
+package validations
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"rest-app/pkg/bank"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	// accountSeparators printed between groups of digits
+	accountSeparators = strings.NewReplacer(" ", "", "-", "", ".", "")
+	// accountNumber digits, receipts may mask some of them, a phone number of an e-wallet may start with +
+	accountNumber = regexp.MustCompile(`^\+?[0-9*xX•]{5,20}$`)
+)
+
+// AccountNumberMatches reports whether account looks like an account number, of bank when it is
+// in the bank registry. Masked digits match any digit
+func AccountNumberMatches(account, bankName string) bool {
+	if !accountNumber.MatchString(accountSeparators.Replace(strings.TrimSpace(account))) {
+		return false
+	}
+
+	institution, ok := bank.Lookup(bankName)
+	return !ok || institution.MatchesAccount(account)
+}
+
+// AccountNumber validate a string field that must look like an account number, of the bank named
+// by BankField when given
+//
+// Usage: `binding:"account_number"` or `binding:"account_number=BankField"`
+func AccountNumber(fl validator.FieldLevel) bool {
+	bankName := ""
+	if param := fl.Param(); param != "" {
+		parent := fl.Parent()
+		if parent.Kind() == reflect.Ptr {
+			parent = parent.Elem()
+		}
+		bankName = parent.FieldByName(param).String()
+	}
+	return AccountNumberMatches(fl.Field().String(), bankName)
+}
@@ -0,0 +1,27 @@
+package validations
+
+import (
+	"time"
+
+	"rest-app/pkg/normalize"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ReceiptDate validate a string field that must be a date as receipts print it, see normalize.Date.
+// A time may follow the date
+//
+// Usage: `binding:"receipt_date"`
+func ReceiptDate(fl validator.FieldLevel) bool {
+	_, date, _ := normalize.Timestamp(fl.Field().String(), "", time.UTC)
+	return !date.IsZero()
+}
+
+// Currency validate a string field that must be a currency code, symbol or name normalize.Currency
+// knows, combine with iso4217 to accept every ISO code
+//
+// Usage: `binding:"currency|iso4217"`
+func Currency(fl validator.FieldLevel) bool {
+	_, ok := normalize.Currency(fl.Field().String())
+	return ok
+}
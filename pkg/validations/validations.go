@@ -1,7 +1,10 @@
 package validations
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
@@ -17,6 +20,9 @@ const (
 	StructValidationGreaterThanEqualFieldIfFieldEqual = "gte_field_if_field_eq"
 	StructValidationMinimumFieldIfFieldEqual          = "min_field_if_field_eq"
 	StructValidationMaximumFieldIfFieldEqual          = "max_field_if_field_eq"
+	StructValidationReceiptDate                       = "receipt_date"
+	StructValidationAccountNumber                     = "account_number"
+	StructValidationCurrency                          = "currency"
 )
 
 // InitStructValidation init struct validation
@@ -30,9 +36,14 @@ func InitStructValidation() {
 		StructValidationGreaterThanEqualFieldIfFieldEqual: GTEFieldIfFieldEqual,
 		StructValidationMinimumFieldIfFieldEqual:          MinFieldIfFieldEqual,
 		StructValidationMaximumFieldIfFieldEqual:          MaxFieldIfFieldEqual,
+		StructValidationReceiptDate:                       ReceiptDate,
+		StructValidationAccountNumber:                     AccountNumber,
+		StructValidationCurrency:                          Currency,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		// errors name fields the way clients send them
+		v.RegisterTagNameFunc(jsonName)
 		for tag, validationFunc := range structValidation {
 			err := v.RegisterValidation(tag, validationFunc)
 			if err != nil {
@@ -41,3 +52,23 @@ func InitStructValidation() {
 		}
 	}
 }
+
+// Struct validates the binding tags of obj like gin does for a request body, the errors name
+// fields by their json name. InitStructValidation must have been called
+func Struct(obj any) validator.ValidationErrors {
+	var errs validator.ValidationErrors
+	if err := binding.Validator.ValidateStruct(obj); errors.As(err, &errs) {
+		return errs
+	} else if err != nil {
+		panic(fmt.Errorf("can not validate %T: %w", obj, err))
+	}
+	return nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,9 @@
+package prompts
+
+import "embed"
+
+// FS the built-in prompt templates, <document_type>.<version>.tmpl for every provider and
+// <document_type>.<version>.<provider>.tmpl for one provider
+//
+//go:embed *.tmpl
+var FS embed.FS
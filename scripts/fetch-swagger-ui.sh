@@ -0,0 +1,35 @@
+#!/usr/bin/env sh
+# Fetches the swagger-ui-dist release pinned in pkg/openapi/swagger-ui/VERSION and extracts the
+# files the binary embeds. The tarball is checked against the sha512 in
+# pkg/openapi/swagger-ui/INTEGRITY; when that file is missing it is written from the npm registry
+# record of the release and has to be committed, later fetches then fail on any other tarball.
+set -eu
+
+dir="$(dirname "$0")/../pkg/openapi/swagger-ui"
+version="$(cat "$dir/VERSION")"
+tarball="https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-$version.tgz"
+
+tmp="$(mktemp -d)"
+trap 'rm -rf "$tmp"' EXIT
+
+curl -sSfL -o "$tmp/package.tgz" "$tarball"
+actual="sha512-$(openssl dgst -sha512 -binary "$tmp/package.tgz" | openssl base64 -A)"
+
+if [ -f "$dir/INTEGRITY" ]; then
+	expected="$(cat "$dir/INTEGRITY")"
+else
+	expected="$(curl -sSfL "https://registry.npmjs.org/swagger-ui-dist/$version" | grep -o '"integrity":"[^"]*"' | head -n 1 | cut -d '"' -f 4)"
+fi
+
+if [ "$actual" != "$expected" ]; then
+	echo "swagger-ui-dist $version: checksum $actual doesn't match $expected" >&2
+	exit 1
+fi
+
+tar -xzf "$tmp/package.tgz" -C "$tmp" package/swagger-ui.css package/swagger-ui-bundle.js package/LICENSE
+cp "$tmp/package/swagger-ui.css" "$tmp/package/swagger-ui-bundle.js" "$tmp/package/LICENSE" "$dir/"
+
+if [ ! -f "$dir/INTEGRITY" ]; then
+	echo "$actual" > "$dir/INTEGRITY"
+	echo "pinned swagger-ui-dist $version to $actual in $dir/INTEGRITY, commit it" >&2
+fi